@@ -6,7 +6,7 @@ require (
 	github.com/actions-go/toolkit v0.0.0-20201110204044-13d92efd7b2e
 	github.com/google/go-github/v48 v48.1.0
 	github.com/stretchr/testify v1.6.1
-	golang.org/x/mod v0.3.0
+	golang.org/x/mod v0.20.0
 	golang.org/x/oauth2 v0.0.0-20200107190931-bf48bf16ab8d
 	gopkg.in/yaml.v2 v2.3.0
 )
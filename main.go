@@ -20,6 +20,7 @@ func main() {
 		repoPath      string
 		configPath    string
 		githubToken   string
+		gitlabToken   string
 		dryRun        bool
 		closeOutdated bool
 	)
@@ -28,6 +29,7 @@ func main() {
 	f.StringVar(&repoPath, "repository", ".", "repository to check dependencies for")
 	f.StringVar(&configPath, "config-path", ".github/depcheck.yml", "config file for the dependency tracker")
 	f.StringVar(&githubToken, "github-token", "", "github token to use")
+	f.StringVar(&gitlabToken, "gitlab-token", "", "gitlab token to use for gitlab_projects")
 	f.BoolVar(&dryRun, "dry-run", false, "don't actually create the issues")
 	f.BoolVar(&closeOutdated, "close-oudated", true, "close oudated issues after creating a new one")
 
@@ -39,6 +41,7 @@ func main() {
 	dryRun = boolOrDefault("dry-run", dryRun)
 	closeOutdated = boolOrDefault("close-oudated", closeOutdated)
 	githubToken = getGithubToken()
+	gitlabToken = getGitlabToken()
 
 	if err := f.Parse(os.Args[1:]); err != nil {
 		log.Fatalln(err)
@@ -52,6 +55,7 @@ func main() {
 	if err != nil {
 		log.Fatalln(err)
 	}
+	cfg.GitlabToken = gitlabToken
 
 	t := tracker.New(cfg, repoPath, cli)
 	deps, err := t.CheckOutdated(context.Background())
@@ -68,8 +72,14 @@ func main() {
 
 	for _, dep := range deps {
 		fmt.Printf("\tName:      %s\n", dep.Name)
+		if dep.Kind != tracker.UpdateKindVersion {
+			fmt.Printf("\tKind:      %s\n", dep.Kind)
+		}
 		fmt.Printf("\tVersion:   %s\n", dep.CurrentVersion)
 		fmt.Printf("\tAvailable: %s\n", dep.LatestVersion)
+		if dep.NewName != "" {
+			fmt.Printf("\tNew name:  %s\n", dep.NewName)
+		}
 		fmt.Println()
 	}
 
@@ -114,6 +124,15 @@ func getGithubToken() string {
 	return ""
 }
 
+// getGitlabToken returns the GitLab token from GITLAB_TOKEN or the
+// gitlab-token input.
+func getGitlabToken() string {
+	if t := os.Getenv("GITLAB_TOKEN"); t != "" {
+		return t
+	}
+	return core.GetInputOrDefault("gitlab-token", "")
+}
+
 func boolOrDefault(name string, defaultValue bool) bool {
 	defaultStr := "false"
 	if defaultValue {
@@ -0,0 +1,228 @@
+package tracker
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/google/go-github/v48/github"
+)
+
+var (
+	// annotationRegexp matches a depcheck annotation in a comment, capturing
+	// the dependency.
+	annotationRegexp = regexp.MustCompile(`^\s*(?:#|//|--|;|<!--|/\*)\s*depcheck:\s*(\S+)`)
+
+	// annotatedVersionRegexp matches the version on the line following an
+	// annotation. Only alpha, beta, and rc prereleases are included, so
+	// suffixes such as "-alpine" in image tags aren't mistaken for versions.
+	annotatedVersionRegexp = regexp.MustCompile(`v?\d+(?:\.\d+)+(?:-(?:alpha|beta|rc)[0-9.]*)?`)
+)
+
+// Annotations checks dependencies declared by "depcheck:" annotations in
+// comments of files in the repository, such as:
+//
+//	# depcheck: github.com/prometheus/node_exporter
+//	NODE_EXPORTER_VERSION ?= v1.7.0
+//
+// The dependency is checked using the version found on the line following
+// the annotation.
+type Annotations struct {
+	repo string
+	cfg  AnnotationsConfig
+
+	cli                    *github.Client
+	gitlabURL, gitlabToken string
+}
+
+// AnnotationsConfig configures scanning the repository for annotations.
+type AnnotationsConfig struct {
+	Enabled bool `yaml:"enabled"`
+
+	// Exclude are globs of files or directories, relative to the repository,
+	// which aren't scanned. Directories named .git are never scanned.
+	Exclude []string `yaml:"exclude"`
+
+	Options DependencyOptions `yaml:",inline"`
+}
+
+// annotation is a dependency declared by an annotation.
+type annotation struct {
+	// Dependency is the annotated dependency, such as
+	// github.com/prometheus/node_exporter.
+	Dependency string
+	Version    string
+	// Location is the file and line of the version, such as "Makefile:12".
+	Location string
+}
+
+// NewAnnotations creates a new Annotations tracker. Annotated dependencies
+// hosted on Github are checked using cli, and those on GitLab are checked
+// using the gitlabURL instance and gitlabToken.
+func NewAnnotations(repo string, cfg AnnotationsConfig, cli *github.Client, gitlabURL, gitlabToken string) *Annotations {
+	return &Annotations{repo: repo, cfg: cfg, cli: cli, gitlabURL: gitlabURL, gitlabToken: gitlabToken}
+}
+
+// CheckOutdated will return the list of annotated dependencies that can be
+// updated. UsedBy is set to the locations of the annotated versions.
+// Dependencies which can't be checked, such as those which don't exist, are
+// logged and skipped, since annotations may be anywhere in the repository.
+func (c *Annotations) CheckOutdated(ctx context.Context) ([]Dependency, error) {
+	annotations, err := c.scan()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		outdated []Dependency
+
+		// index maps a dependency and version to the positions of its
+		// updates in outdated, so each is only checked once.
+		index = make(map[[2]string][]int)
+	)
+	for _, a := range annotations {
+		key := [2]string{a.Dependency, a.Version}
+		if positions, ok := index[key]; ok {
+			for _, i := range positions {
+				outdated[i].UsedBy = append(outdated[i].UsedBy, a.Location)
+			}
+			continue
+		}
+
+		// Dependencies which can't be checked are only logged once.
+		index[key] = nil
+
+		deps, err := c.tracker(a).CheckOutdated(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			log.Printf("Ignoring annotated dependency %s in %s: %s", a.Dependency, a.Location, err)
+			continue
+		}
+		positions := []int{}
+		for _, dep := range deps {
+			dep.UsedBy = []string{a.Location}
+			positions = append(positions, len(outdated))
+			outdated = append(outdated, dep)
+		}
+		index[key] = positions
+	}
+
+	return outdated, nil
+}
+
+// tracker returns the tracker to check an annotated dependency with, based
+// on where it is hosted. Dependencies which aren't on Github or a known
+// GitLab instance are checked as git remotes, using https if no scheme is
+// given.
+func (c *Annotations) tracker(a annotation) Tracker {
+	gitlabURL := c.gitlabURL
+	if gitlabURL == "" {
+		gitlabURL = defaultGitlabURL
+	}
+	var gitlabHost string
+	if u, err := url.Parse(gitlabURL); err == nil {
+		gitlabHost = u.Host
+	}
+
+	switch {
+	case strings.HasPrefix(a.Dependency, "github.com/"):
+		return NewGithub(c.repo, []GithubDependency{{
+			Project: a.Dependency,
+			Version: a.Version,
+			Options: c.cfg.Options,
+		}}, c.cli)
+	case gitlabHost != "" && strings.HasPrefix(a.Dependency, gitlabHost+"/"):
+		return NewGitlab(c.repo, []GitlabDependency{{
+			Project: a.Dependency,
+			Version: a.Version,
+			Options: c.cfg.Options,
+		}}, gitlabURL, c.gitlabToken)
+	case strings.HasPrefix(a.Dependency, "gitlab.com/"):
+		// The token is for another instance, so it isn't sent to gitlab.com.
+		return NewGitlab(c.repo, []GitlabDependency{{
+			Project: a.Dependency,
+			Version: a.Version,
+			Options: c.cfg.Options,
+		}}, defaultGitlabURL, "")
+	default:
+		remote := a.Dependency
+		if !strings.Contains(remote, "://") {
+			remote = "https://" + remote
+		}
+		return NewGit(c.repo, []GitDependency{{
+			Remote:  remote,
+			Version: a.Version,
+			Options: c.cfg.Options,
+		}})
+	}
+}
+
+// scan returns the annotations in files of the repository.
+func (c *Annotations) scan() ([]annotation, error) {
+	var annotations []annotation
+
+	err := walkRepo(c.repo, c.cfg.Exclude, func(rel string, b []byte) error {
+		annotations = append(annotations, readAnnotations(rel, b)...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan for annotations: %w", err)
+	}
+	return annotations, nil
+}
+
+// readAnnotations returns the annotations in a file. Binary files are
+// skipped. Annotations without a version on the following line, such as
+// examples in documentation, are logged and skipped.
+func readAnnotations(name string, b []byte) []annotation {
+	if bytes.IndexByte(b, 0) >= 0 {
+		return nil
+	}
+
+	var (
+		annotations []annotation
+
+		// pending is the dependency of an annotation on the previous line.
+		pending string
+		line    int
+	)
+	s := bufio.NewScanner(bytes.NewReader(b))
+	for s.Scan() {
+		line++
+
+		if pending != "" {
+			version := annotatedVersionRegexp.FindString(s.Text())
+			if version != "" {
+				annotations = append(annotations, annotation{
+					Dependency: pending,
+					Version:    version,
+					Location:   fmt.Sprintf("%s:%d", name, line),
+				})
+				pending = ""
+				continue
+			}
+			log.Printf("Ignoring depcheck annotation for %s in %s:%d: no version found on the next line", pending, name, line-1)
+			pending = ""
+		}
+
+		if m := annotationRegexp.FindStringSubmatch(s.Text()); m != nil {
+			pending = strings.TrimSuffix(m[1], "-->")
+		}
+	}
+	if err := s.Err(); err != nil {
+		// Lines too long to scan aren't expected in hand-written files.
+		log.Printf("Ignoring annotations in %s: %s", name, err)
+		return nil
+	}
+	if pending != "" {
+		log.Printf("Ignoring depcheck annotation for %s in %s:%d: no version found on the next line", pending, name, line)
+	}
+	return annotations
+}
@@ -0,0 +1,106 @@
+package tracker
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadAnnotations(t *testing.T) {
+	input := `# depcheck: github.com/prometheus/node_exporter
+NODE_EXPORTER_VERSION ?= v1.7.0
+
+// depcheck: gitlab.com/gitlab-org/gitlab-runner
+const runnerVersion = "16.0.0-rc1"
+
+<!-- depcheck: git.sr.ht/~sircmpwn/hare -->
+Built with hare 0.24.0.
+
+    # depcheck: github.com/grafana/agent
+    FROM grafana/agent:v0.40.2-alpine
+`
+
+	annotations := readAnnotations("Makefile", []byte(input))
+	require.Equal(t, []annotation{
+		{Dependency: "github.com/prometheus/node_exporter", Version: "v1.7.0", Location: "Makefile:2"},
+		{Dependency: "gitlab.com/gitlab-org/gitlab-runner", Version: "16.0.0-rc1", Location: "Makefile:5"},
+		{Dependency: "git.sr.ht/~sircmpwn/hare", Version: "0.24.0", Location: "Makefile:8"},
+		{Dependency: "github.com/grafana/agent", Version: "v0.40.2", Location: "Makefile:11"},
+	}, annotations)
+
+	// Annotations without a version are skipped.
+	annotations = readAnnotations("Makefile", []byte("# depcheck: github.com/grafana/agent\n# depcheck: github.com/grafana/loki\nLOKI_VERSION ?= v2.9.0\n# depcheck: github.com/grafana/agent\n"))
+	require.Equal(t, []annotation{
+		{Dependency: "github.com/grafana/loki", Version: "v2.9.0", Location: "Makefile:3"},
+	}, annotations)
+}
+
+func TestAnnotations_CheckOutdated(t *testing.T) {
+	repo := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(repo, "docs"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(repo, "scripts"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(repo, "vendor"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(repo, "tools", "vendor"), 0755))
+	files := map[string]string{
+		"Makefile":           "# depcheck: github.com/grafana/agent\nAGENT_VERSION ?= v0.30.0\n",
+		"scripts/install.sh": "#!/bin/sh\n\n# depcheck: github.com/grafana/agent\nAGENT_VERSION=v0.30.0\n",
+		"scripts/missing.sh": "# depcheck: github.com/grafana/missing\nMISSING_VERSION=v1.0.0\n",
+		"vendor/Makefile":    "# depcheck: github.com/grafana/loki\nLOKI_VERSION ?= v1.0.0\n",
+		"tools/vendor/x.mk":  "# depcheck: github.com/grafana/loki\nLOKI_VERSION ?= v1.0.0\n",
+		"docs/README.md":     "Add a comment like:\n\n    # depcheck: github.com/grafana/agent\n\nabove the version.\n",
+	}
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(repo, name), []byte(content), 0644))
+	}
+
+	var requests int
+	cli := newTestGithubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/grafana/agent/tags":
+			requests++
+			fmt.Fprint(w, `[{"name": "v0.31.0"}, {"name": "v0.30.0"}]`)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	a := NewAnnotations(repo, AnnotationsConfig{Enabled: true, Exclude: []string{"vendor"}}, cli, "", "")
+	deps, err := a.CheckOutdated(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []Dependency{{
+		Name:           "github.com/grafana/agent",
+		CurrentVersion: "v0.30.0",
+		LatestVersion:  "v0.31.0",
+		UsedBy:         []string{"Makefile:2", "scripts/install.sh:4"},
+	}}, deps)
+
+	// Annotations without a version, dependencies which can't be checked,
+	// and excluded directories at any depth are skipped. Annotations with the
+	// same dependency and version are only checked once.
+	require.Equal(t, 1, requests)
+}
+
+func TestAnnotations_GitlabToken(t *testing.T) {
+	tt := []struct {
+		gitlabURL, dependency string
+		baseURL, token        string
+	}{
+		{gitlabURL: "", dependency: "gitlab.com/group/project", baseURL: defaultGitlabURL, token: "secret"},
+		{gitlabURL: "https://gitlab.example.com", dependency: "gitlab.example.com/group/project", baseURL: "https://gitlab.example.com", token: "secret"},
+		// The token of a self-managed instance isn't sent to gitlab.com.
+		{gitlabURL: "https://gitlab.example.com", dependency: "gitlab.com/group/project", baseURL: defaultGitlabURL},
+	}
+
+	for _, tc := range tt {
+		c := NewAnnotations(t.TempDir(), AnnotationsConfig{}, nil, tc.gitlabURL, "secret")
+		g, ok := c.tracker(annotation{Dependency: tc.dependency, Version: "v1.0.0"}).(*Gitlab)
+		require.True(t, ok, tc.dependency)
+		require.Equal(t, tc.baseURL, g.baseURL, tc.dependency)
+		require.Equal(t, tc.token, g.token, tc.dependency)
+	}
+}
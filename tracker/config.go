@@ -4,14 +4,20 @@ import (
 	"fmt"
 	"os"
 	"regexp"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v2"
 )
 
 var DefaultConfig = Config{
-	IssueTitleTemplate: "Update {{.Name}} to {{.LatestVersion}}",
-	IssueTextTemplate:  "An update for `{{.Name}}` (version `{{.LatestVersion}}`) is now available. Version `{{.CurrentVersion}}` is currently in use.",
+	IssueTitleTemplate: "Update {{.Name}} to {{with .NewName}}{{.}}@{{end}}{{.LatestVersion}}",
+	IssueTextTemplate:  "An update for `{{.Name}}` (version `{{.LatestVersion}}`) is now available. Version `{{.CurrentVersion}}` is currently in use.{{with .NewName}} The new version is published as `{{.}}`.{{end}}",
 	OutdatedLabel:      "outdated-dependency",
+
+	DeprecationTitleTemplate: `{{if eq .Kind "retracted"}}{{.Name}} {{.CurrentVersion}} is retracted{{else if eq .Kind "unsupported"}}{{.Name}} {{.CurrentVersion}} is no longer supported{{else}}{{.Name}} is deprecated{{end}}`,
+	DeprecationTextTemplate:  "{{if eq .Kind \"retracted\"}}Version `{{.CurrentVersion}}` of `{{.Name}}` is in use but has been retracted{{with .LatestVersion}}; version `{{.}}` is available{{end}}.{{else if eq .Kind \"unsupported\"}}Version `{{.CurrentVersion}}` of `{{.Name}}` is in use but is no longer supported; version `{{.LatestVersion}}` is available.{{else}}`{{.Name}}` is deprecated.{{end}}{{with .Reason}}\n\n> {{.}}{{end}}",
+	DeprecationLabel:         "deprecated-dependency",
 }
 
 // Config represents the tracker configuration.
@@ -28,11 +34,54 @@ type Config struct {
 	// OutdatedLabel is the label to attach to created issues.
 	OutdatedLabel string `yaml:"outdated_label"`
 
-	// GoModules are a list of go module dependencies to check.
-	GoModules []GoModule `yaml:"go_modules"`
+	// DeprecationTitleTemplate, DeprecationTextTemplate, and DeprecationLabel
+	// are used instead of IssueTitleTemplate, IssueTextTemplate, and
+	// OutdatedLabel for dependencies which are retracted or deprecated.
+	DeprecationTitleTemplate string `yaml:"deprecation_title_template"`
+	DeprecationTextTemplate  string `yaml:"deprecation_text_template"`
+	DeprecationLabel         string `yaml:"deprecation_label"`
+
+	// GoModules configures the go module dependencies to check.
+	GoModules GoModulesConfig `yaml:"go_modules"`
+
+	// GoToolchain configures the Go versions to check.
+	GoToolchain GoToolchainConfig `yaml:"go_toolchain"`
 
 	// GithubDeps are a list of github repos to check.
 	GithubDeps []GithubDependency `yaml:"github_repos"`
+
+	// Forks are a list of forks of Github projects to compare against their
+	// upstream.
+	Forks []ForkDependency `yaml:"forks"`
+
+	// GitlabDeps are a list of GitLab projects to check.
+	GitlabDeps []GitlabDependency `yaml:"gitlab_projects"`
+
+	// GitlabURL is the URL of the GitLab instance hosting GitlabDeps, unless
+	// overridden by a dependency. Defaults to https://gitlab.com.
+	GitlabURL string `yaml:"gitlab_url"`
+
+	// GitlabToken authenticates requests to GitLab. It is never read from
+	// the config file; see the -gitlab-token flag.
+	GitlabToken string `yaml:"-"`
+
+	// GitDeps are a list of git repositories to check.
+	GitDeps []GitDependency `yaml:"git_repos"`
+
+	// ContainerImages are a list of container images to check.
+	ContainerImages []ContainerImage `yaml:"container_images"`
+
+	// ImageDiscovery configures checking container images referenced by
+	// Dockerfiles and YAML manifests in the repository.
+	ImageDiscovery ImageDiscoveryConfig `yaml:"image_discovery"`
+
+	// Annotations configures checking dependencies declared by "depcheck:"
+	// annotations in files of the repository.
+	Annotations AnnotationsConfig `yaml:"annotations"`
+
+	// JsonnetDeps are a list of directories containing a jsonnetfile.json
+	// whose dependencies should be checked.
+	JsonnetDeps []JsonnetFile `yaml:"jsonnet_deps"`
 }
 
 // DependencyOptions are options for individual dependencies.
@@ -40,6 +89,95 @@ type DependencyOptions struct {
 	// IgnoreVersionPattern is a pattern that allows you to ignore specific
 	// versions matching the given string.
 	IgnoreVersionPattern *Regexp `yaml:"ignore_version_pattern"`
+
+	// UpdatePolicy limits which updates are reported. Defaults to
+	// UpdatePolicyMajor.
+	UpdatePolicy UpdatePolicy `yaml:"update_policy"`
+
+	// Ignore is a constraint of versions to ignore, such as ">= 3.0.0".
+	Ignore *Constraint `yaml:"ignore"`
+
+	// Allowed is a constraint of versions which may be reported, such as
+	// "~1.4". When set, versions which don't match are ignored.
+	Allowed *Constraint `yaml:"allowed"`
+
+	// MinAge is how long a version must have been published before it is
+	// reported, such as "72h".
+	MinAge time.Duration `yaml:"min_age"`
+
+	// VersionScheme is how versions are parsed and ordered. Defaults to
+	// VersionSchemeSemver.
+	VersionScheme VersionSchemeName `yaml:"version_scheme"`
+
+	// VersionPattern extracts versions when VersionScheme is
+	// VersionSchemeRegex.
+	VersionPattern *Regexp `yaml:"version_pattern"`
+}
+
+// UpdatePolicy limits the updates reported for a dependency relative to its
+// current version.
+type UpdatePolicy string
+
+// Supported UpdatePolicy values.
+const (
+	// UpdatePolicyMajor reports any update.
+	UpdatePolicyMajor UpdatePolicy = "major"
+	// UpdatePolicyMinor reports minor and patch updates within the current
+	// major version.
+	UpdatePolicyMinor UpdatePolicy = "minor"
+	// UpdatePolicyPatch reports patch updates within the current minor
+	// version.
+	UpdatePolicyPatch UpdatePolicy = "patch"
+)
+
+// UnmarshalYAML unmarshals an UpdatePolicy, ensuring that it is valid.
+func (p *UpdatePolicy) UnmarshalYAML(f func(v interface{}) error) error {
+	var s string
+	if err := f(&s); err != nil {
+		return err
+	}
+	switch v := UpdatePolicy(s); v {
+	case UpdatePolicyMajor, UpdatePolicyMinor, UpdatePolicyPatch:
+		*p = v
+		return nil
+	default:
+		return fmt.Errorf("invalid update policy %q: expected %q, %q, or %q", s, UpdatePolicyMajor, UpdatePolicyMinor, UpdatePolicyPatch)
+	}
+}
+
+// TagFilter selects the tags of a repository which belong to a single
+// component, for repositories which publish multiple components with tags
+// like operator/v0.30.0 or helm-chart-4.2.0.
+type TagFilter struct {
+	// TagPrefix limits tags to those starting with the prefix. The prefix is
+	// removed from tags to get the version.
+	TagPrefix string `yaml:"tag_prefix"`
+
+	// TagPattern limits tags to those matching the regex. The version is
+	// extracted from matching tags with Regexp.Extract.
+	TagPattern *Regexp `yaml:"tag_pattern"`
+}
+
+// Version returns the version of a tag. ok is false if the tag is filtered
+// out.
+func (f *TagFilter) Version(tag string) (version string, ok bool) {
+	version = tag
+	if f.TagPrefix != "" {
+		if !strings.HasPrefix(version, f.TagPrefix) {
+			return "", false
+		}
+		version = strings.TrimPrefix(version, f.TagPrefix)
+	}
+	if f.TagPattern != nil {
+		return f.TagPattern.Extract(version)
+	}
+	return version, true
+}
+
+// Component returns the name of the component selected by the filter, which
+// is derived from TagPrefix. It is empty if there is no TagPrefix.
+func (f *TagFilter) Component() string {
+	return strings.TrimRight(f.TagPrefix, "/-_.@")
 }
 
 // Regexp is a regex that can be unmarshaled from a string.
@@ -53,6 +191,24 @@ func (r *Regexp) Matches(s string) bool {
 	return (*regexp.Regexp)(r).MatchString(s)
 }
 
+// Extract returns the portion of s captured by the capture group named
+// "version", the first capture group, or the whole match, in that order. ok
+// is false if s doesn't match.
+func (r *Regexp) Extract(s string) (version string, ok bool) {
+	re := (*regexp.Regexp)(r)
+
+	m := re.FindStringSubmatch(s)
+	if m == nil {
+		return "", false
+	}
+	if i := re.SubexpIndex("version"); i >= 0 {
+		return m[i], true
+	} else if len(m) > 1 {
+		return m[1], true
+	}
+	return m[0], true
+}
+
 func (r *Regexp) UnmarshalYAML(f func(v interface{}) error) error {
 	var s string
 	if err := f(&s); err != nil {
@@ -73,9 +229,41 @@ func (c *Config) UnmarshalYAML(f func(v interface{}) error) error {
 	if err := f((*config)(c)); err != nil {
 		return err
 	}
+	if err := c.validate(); err != nil {
+		return err
+	}
 	return c.SetDefaults()
 }
 
+// validate returns an error for options which can't be applied to the
+// dependencies they're set for.
+func (c *Config) validate() error {
+	// Git remotes and container registries don't record when tags were
+	// created, so min_age can't be checked.
+	for _, d := range c.GitDeps {
+		if d.Options.MinAge > 0 {
+			return fmt.Errorf("git_repos: %s: min_age isn't supported, since git doesn't record when tags were published", d.Remote)
+		}
+	}
+	for _, d := range c.ContainerImages {
+		if d.Options.MinAge > 0 {
+			return fmt.Errorf("container_images: %s: min_age isn't supported, since registries don't record when tags were pushed", d.Image)
+		}
+	}
+	if c.GoToolchain.Options.MinAge > 0 {
+		return fmt.Errorf("go_toolchain: min_age isn't supported, since the Go release index doesn't record when versions were released")
+	}
+	if c.ImageDiscovery.Options.MinAge > 0 {
+		return fmt.Errorf("image_discovery: min_age isn't supported, since registries don't record when tags were pushed")
+	}
+	for _, d := range c.JsonnetDeps {
+		if d.Options.MinAge > 0 {
+			return fmt.Errorf("jsonnet_deps: %s: min_age isn't supported, since dependencies are checked with git, which doesn't record when tags were published", d.Dir)
+		}
+	}
+	return nil
+}
+
 // SetDefaults applies default values to fields in the config.
 func (c *Config) SetDefaults() error {
 	if c.IssueRepository == "" {
@@ -98,7 +286,11 @@ func LoadConfig(path string) (*Config, error) {
 		return nil, fmt.Errorf("failed to open config: %w", err)
 	}
 
+	// Options such as regexes and version constraints are validated while
+	// decoding.
 	var c Config
-	err = yaml.NewDecoder(f).Decode(&c)
-	return &c, err
+	if err := yaml.NewDecoder(f).Decode(&c); err != nil {
+		return nil, fmt.Errorf("failed to load config %s: %w", path, err)
+	}
+	return &c, nil
 }
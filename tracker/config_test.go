@@ -3,6 +3,7 @@ package tracker
 import (
 	"regexp"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"gopkg.in/yaml.v2"
@@ -17,3 +18,84 @@ func TestParseRegex(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, expect, &actual)
 }
+
+func TestParseUpdatePolicy(t *testing.T) {
+	var actual UpdatePolicy
+	require.NoError(t, yaml.Unmarshal([]byte(`patch`), &actual))
+	require.Equal(t, UpdatePolicyPatch, actual)
+
+	err := yaml.Unmarshal([]byte(`latest`), &actual)
+	require.EqualError(t, err, `invalid update policy "latest": expected "major", "minor", or "patch"`)
+}
+
+func TestTagFilter(t *testing.T) {
+	tt := []struct {
+		filter TagFilter
+		tag    string
+		expect string
+		ok     bool
+	}{
+		{filter: TagFilter{}, tag: "v1.0.0", expect: "v1.0.0", ok: true},
+		{filter: TagFilter{TagPrefix: "operator/"}, tag: "operator/v0.30.0", expect: "v0.30.0", ok: true},
+		{filter: TagFilter{TagPrefix: "operator/"}, tag: "v0.30.0", ok: false},
+		{
+			filter: TagFilter{TagPattern: (*Regexp)(regexp.MustCompile(`^helm-chart-(?P<version>.+)$`))},
+			tag:    "helm-chart-4.2.0",
+			expect: "4.2.0",
+			ok:     true,
+		},
+		{
+			filter: TagFilter{TagPattern: (*Regexp)(regexp.MustCompile(`^helm-chart-(?P<version>.+)$`))},
+			tag:    "controller-v1.9.0",
+			ok:     false,
+		},
+	}
+
+	for _, tc := range tt {
+		actual, ok := tc.filter.Version(tc.tag)
+		require.Equal(t, tc.ok, ok, "unexpected result for %s", tc.tag)
+		require.Equal(t, tc.expect, actual)
+	}
+}
+
+func TestConfig_MinAgeUnsupported(t *testing.T) {
+	t.Setenv("GITHUB_REPOSITORY", "rfratto/depcheck")
+
+	tt := []struct {
+		input  string
+		expect string
+	}{
+		{
+			input:  "git_repos:\n- remote: https://example.com/project.git\n  version: v1.0.0\n  min_age: 72h",
+			expect: "git_repos: https://example.com/project.git: min_age isn't supported, since git doesn't record when tags were published",
+		},
+		{
+			input:  "container_images:\n- image: grafana/grafana\n  version: 10.0.0\n  min_age: 72h",
+			expect: "container_images: grafana/grafana: min_age isn't supported, since registries don't record when tags were pushed",
+		},
+		{
+			input:  "go_toolchain:\n  go_mod: [go.mod]\n  min_age: 72h",
+			expect: "go_toolchain: min_age isn't supported, since the Go release index doesn't record when versions were released",
+		},
+		{
+			input:  "image_discovery:\n  enabled: true\n  min_age: 72h",
+			expect: "image_discovery: min_age isn't supported, since registries don't record when tags were pushed",
+		},
+		{
+			input:  "jsonnet_deps:\n- dir: operations\n  min_age: 72h",
+			expect: "jsonnet_deps: operations: min_age isn't supported, since dependencies are checked with git, which doesn't record when tags were published",
+		},
+	}
+
+	for _, tc := range tt {
+		var actual Config
+		err := yaml.Unmarshal([]byte(tc.input), &actual)
+		require.EqualError(t, err, tc.expect)
+	}
+
+	// Trackers which know when versions were published accept min_age.
+	var actual Config
+	err := yaml.Unmarshal([]byte("github_repos:\n- project: github.com/grafana/agent\n  version: v0.30.0\n  min_age: 72h"), &actual)
+	require.NoError(t, err)
+	require.Equal(t, 72*time.Hour, actual.GithubDeps[0].Options.MinAge)
+}
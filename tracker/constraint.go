@@ -0,0 +1,278 @@
+package tracker
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Constraint is a version constraint which can be unmarshaled from a string,
+// such as ">= 1.2.0, < 2.0.0" or "~1.4 || ^2.1". Constraints are evaluated
+// against parsed versions, so they apply to any version scheme.
+//
+// A constraint is a list of alternatives separated by "||". Each alternative
+// is a list of conditions separated by commas or spaces, all of which must
+// match. A condition is an operator followed by a version. Supported
+// operators are =, !=, >, >=, <, <=, ~ (or ~>), and ^; a condition without an
+// operator is the same as =. Versions may omit the "v" prefix and may be
+// partial (1.4) or use wildcards (1.4.x, *). A partial version matches every
+// version with the same prefix, so "= 1.4" matches 1.4.0 through 1.4.x.
+//
+// Upper bounds implied by partial versions, ~, and ^ exclude prereleases of
+// the next version, so "~1.4" does not match 1.5.0-rc.1.
+type Constraint struct {
+	raw          string
+	alternatives [][]versionRange
+}
+
+// ParseConstraint parses a version constraint.
+func ParseConstraint(s string) (*Constraint, error) {
+	c := &Constraint{raw: s}
+
+	for _, alt := range strings.Split(s, "||") {
+		conds, err := parseConditions(alt)
+		if err != nil {
+			return nil, fmt.Errorf("invalid constraint %q: %w", s, err)
+		}
+		if len(conds) == 0 {
+			return nil, fmt.Errorf("invalid constraint %q: empty condition", s)
+		}
+		c.alternatives = append(c.alternatives, conds)
+	}
+	return c, nil
+}
+
+// Matches returns true if the version v satisfies the constraint.
+func (c *Constraint) Matches(v Version) bool {
+	if c == nil {
+		return false
+	}
+
+Alternatives:
+	for _, alt := range c.alternatives {
+		for _, r := range alt {
+			if !r.contains(v) {
+				continue Alternatives
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// String returns the original text of the constraint.
+func (c *Constraint) String() string { return c.raw }
+
+// UnmarshalYAML unmarshals a Constraint from a string.
+func (c *Constraint) UnmarshalYAML(f func(v interface{}) error) error {
+	var s string
+	if err := f(&s); err != nil {
+		return err
+	}
+	parsed, err := ParseConstraint(s)
+	if err != nil {
+		return err
+	}
+	*c = *parsed
+	return nil
+}
+
+// versionRange is a range of versions. Nil bounds are unbounded.
+type versionRange struct {
+	lo, hi         *Version
+	loIncl, hiIncl bool
+
+	// negate inverts the range, matching only versions outside of it.
+	negate bool
+}
+
+func (r versionRange) contains(v Version) bool {
+	in := true
+	if r.lo != nil {
+		cmp := v.Compare(*r.lo)
+		in = in && (cmp > 0 || (cmp == 0 && r.loIncl))
+	}
+	if r.hi != nil {
+		cmp := v.Compare(*r.hi)
+		in = in && (cmp < 0 || (cmp == 0 && r.hiIncl))
+	}
+	return in != r.negate
+}
+
+var constraintOperators = []string{"!=", ">=", "<=", "~>", "==", "=", ">", "<", "~", "^"}
+
+// parseConditions parses a list of conditions separated by commas or spaces.
+// An operator may be separated from its version by spaces.
+func parseConditions(s string) ([]versionRange, error) {
+	var (
+		ranges []versionRange
+		fields = strings.Fields(strings.ReplaceAll(s, ",", " "))
+	)
+
+	for i := 0; i < len(fields); i++ {
+		var op string
+		for _, candidate := range constraintOperators {
+			if strings.HasPrefix(fields[i], candidate) {
+				op = candidate
+				break
+			}
+		}
+
+		version := strings.TrimPrefix(fields[i], op)
+		if version == "" && op != "" {
+			if i+1 == len(fields) {
+				return nil, fmt.Errorf("missing version after %q", op)
+			}
+			i++
+			version = fields[i]
+		}
+
+		r, err := parseCondition(op, version)
+		if err != nil {
+			return nil, err
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges, nil
+}
+
+// parseCondition converts a single condition into a versionRange.
+func parseCondition(op, version string) (versionRange, error) {
+	pv, err := parsePartialVersion(version)
+	if err != nil {
+		return versionRange{}, err
+	}
+	var (
+		base = pv.base()
+		next = pv.next()
+	)
+
+	switch op {
+	case "", "=", "==", "!=":
+		r := versionRange{lo: base, loIncl: true, hi: next}
+		if pv.n == 3 {
+			r.hi, r.hiIncl = base, true
+		}
+		if pv.n == 0 {
+			r = versionRange{}
+		}
+		r.negate = op == "!="
+		return r, nil
+	case ">":
+		if pv.n == 0 {
+			return versionRange{}, fmt.Errorf("%q matches no versions", op+version)
+		}
+		if pv.n == 3 {
+			return versionRange{lo: base}, nil
+		}
+		return versionRange{lo: next, loIncl: true}, nil
+	case ">=":
+		return versionRange{lo: base, loIncl: true}, nil
+	case "<":
+		if pv.n == 0 {
+			return versionRange{}, fmt.Errorf("%q matches no versions", op+version)
+		}
+		return versionRange{hi: base}, nil
+	case "<=":
+		if pv.n == 3 {
+			return versionRange{hi: base, hiIncl: true}, nil
+		}
+		return versionRange{hi: next}, nil
+	case "~", "~>":
+		if pv.n == 1 {
+			return versionRange{lo: base, loIncl: true, hi: next}, nil
+		}
+		return versionRange{lo: base, loIncl: true, hi: lowestVersion(pv.major, pv.minor+1, 0)}, nil
+	case "^":
+		switch {
+		case pv.major > 0 || pv.n <= 1:
+			return versionRange{lo: base, loIncl: true, hi: lowestVersion(pv.major+1, 0, 0)}, nil
+		case pv.minor > 0 || pv.n == 2:
+			return versionRange{lo: base, loIncl: true, hi: lowestVersion(0, pv.minor+1, 0)}, nil
+		default:
+			return versionRange{lo: base, loIncl: true, hi: lowestVersion(0, 0, pv.patch+1)}, nil
+		}
+	}
+	return versionRange{}, fmt.Errorf("unsupported operator %q", op)
+}
+
+// partialVersion is a version which may be missing trailing components.
+type partialVersion struct {
+	major, minor, patch int
+	prerelease          string
+
+	// n is the number of components which were specified.
+	n int
+}
+
+func parsePartialVersion(s string) (partialVersion, error) {
+	var pv partialVersion
+
+	rest := strings.TrimPrefix(s, "v")
+	if i := strings.IndexAny(rest, "-+"); i >= 0 {
+		if rest[i] == '-' {
+			pv.prerelease = rest[i+1:]
+			if j := strings.IndexByte(pv.prerelease, '+'); j >= 0 {
+				pv.prerelease = pv.prerelease[:j]
+			}
+		}
+		rest = rest[:i]
+	}
+
+	parts := strings.Split(rest, ".")
+	if len(parts) > 3 {
+		return pv, fmt.Errorf("invalid version %q", s)
+	}
+	for i, p := range parts {
+		if p == "x" || p == "X" || p == "*" {
+			break
+		}
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return pv, fmt.Errorf("invalid version %q", s)
+		}
+		switch i {
+		case 0:
+			pv.major = n
+		case 1:
+			pv.minor = n
+		case 2:
+			pv.patch = n
+		}
+		pv.n++
+	}
+	if pv.n < len(parts) && pv.n != len(parts)-1 {
+		return pv, fmt.Errorf("invalid version %q: wildcards must be the last component", s)
+	}
+	if pv.prerelease != "" && pv.n != 3 {
+		return pv, fmt.Errorf("invalid version %q: prereleases require a full version", s)
+	}
+	return pv, nil
+}
+
+// base returns the lowest version matching pv.
+func (pv partialVersion) base() *Version {
+	return &Version{
+		Segments:   []int{pv.major, pv.minor, pv.patch},
+		Prerelease: pv.prerelease,
+	}
+}
+
+// next returns the lowest version (including prereleases) which is higher
+// than every version matching pv. It is nil for full versions and wildcards.
+func (pv partialVersion) next() *Version {
+	switch pv.n {
+	case 1:
+		return lowestVersion(pv.major+1, 0, 0)
+	case 2:
+		return lowestVersion(pv.major, pv.minor+1, 0)
+	default:
+		return nil
+	}
+}
+
+// lowestVersion returns the lowest version with the given segments,
+// including prereleases.
+func lowestVersion(major, minor, patch int) *Version {
+	return &Version{Segments: []int{major, minor, patch}, Prerelease: "0"}
+}
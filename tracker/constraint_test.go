@@ -0,0 +1,110 @@
+package tracker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+func TestConstraint_Matches(t *testing.T) {
+	tt := []struct {
+		constraint string
+		matches    []string
+		rejects    []string
+	}{
+		{
+			constraint: ">= 3.0.0",
+			matches:    []string{"v3.0.0", "v3.1.0", "v10.0.0"},
+			rejects:    []string{"v2.9.9", "v3.0.0-rc.1"},
+		},
+		{
+			constraint: "~1.4",
+			matches:    []string{"v1.4.0", "v1.4.9"},
+			rejects:    []string{"v1.3.9", "v1.5.0", "v1.5.0-rc.1"},
+		},
+		{
+			constraint: "~1.4.2",
+			matches:    []string{"v1.4.2", "v1.4.3"},
+			rejects:    []string{"v1.4.1", "v1.5.0"},
+		},
+		{
+			constraint: "^1.4.2",
+			matches:    []string{"v1.4.2", "v1.9.0"},
+			rejects:    []string{"v1.4.1", "v2.0.0", "v2.0.0-rc.1"},
+		},
+		{
+			constraint: "^0.4.2",
+			matches:    []string{"v0.4.2", "v0.4.9"},
+			rejects:    []string{"v0.5.0"},
+		},
+		{
+			constraint: "1.x",
+			matches:    []string{"v1.0.0", "1.9.3"},
+			rejects:    []string{"v2.0.0", "v0.9.0"},
+		},
+		{
+			constraint: "1.2.3",
+			matches:    []string{"v1.2.3"},
+			rejects:    []string{"v1.2.4"},
+		},
+		{
+			constraint: "!= 1.2",
+			matches:    []string{"v1.1.0", "v1.3.0"},
+			rejects:    []string{"v1.2.0", "v1.2.7"},
+		},
+		{
+			constraint: ">1.2, <= 1.4",
+			matches:    []string{"v1.3.0", "v1.4.5"},
+			rejects:    []string{"v1.2.9", "v1.5.0"},
+		},
+		{
+			constraint: "< 1.0 || >= 2.0",
+			matches:    []string{"v0.9.0", "v2.0.0"},
+			rejects:    []string{"v1.0.0", "v1.9.0"},
+		},
+		{
+			constraint: "*",
+			matches:    []string{"v0.0.1", "v100.0.0"},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.constraint, func(t *testing.T) {
+			c, err := ParseConstraint(tc.constraint)
+			require.NoError(t, err)
+
+			for _, v := range tc.matches {
+				require.True(t, c.Matches(mustParseVersion(t, v)), "expected %s to match", v)
+			}
+			for _, v := range tc.rejects {
+				require.False(t, c.Matches(mustParseVersion(t, v)), "expected %s to not match", v)
+			}
+		})
+	}
+}
+
+func TestParseConstraint_Invalid(t *testing.T) {
+	for _, input := range []string{"", ">=", "1.x.3", "~foo", "1.2.3.4", "1.2-rc.1", "1.0 ||"} {
+		_, err := ParseConstraint(input)
+		require.Error(t, err, "expected %q to be invalid", input)
+	}
+}
+
+func TestParseConstraint_YAML(t *testing.T) {
+	var opts DependencyOptions
+	err := yaml.Unmarshal([]byte(`{"ignore": ">= 3.0.0", "allowed": "~1.4"}`), &opts)
+	require.NoError(t, err)
+	require.Equal(t, ">= 3.0.0", opts.Ignore.String())
+	require.Equal(t, "~1.4", opts.Allowed.String())
+
+	err = yaml.Unmarshal([]byte(`{"allowed": "~> one"}`), &opts)
+	require.Error(t, err)
+}
+
+func mustParseVersion(t *testing.T, s string) Version {
+	t.Helper()
+	v, ok := semverScheme{}.Parse(s)
+	require.True(t, ok, "invalid version %s", s)
+	return v
+}
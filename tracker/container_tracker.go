@@ -0,0 +1,209 @@
+package tracker
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// containerTagRegexp splits a container image tag into an optional "v"
+// prefix, a version, and a variant suffix, such as "3.19.0" and "-alpine" for
+// 3.19.0-alpine.
+var containerTagRegexp = regexp.MustCompile(`^(v?)(\d+(?:\.\d+)*)(.*)$`)
+
+// ContainerImages checks for outdated container images by listing tags from
+// their registries.
+type ContainerImages struct {
+	check    []ContainerImage
+	registry *registryClient
+
+	// tags caches the tags of each image, since the same image is often
+	// used with several tags.
+	tags map[imageRef][]string
+}
+
+// ContainerImage is a dependency on a container image.
+type ContainerImage struct {
+	// Image is the name of the image without a tag, such as grafana/grafana
+	// or ghcr.io/grafana/agent.
+	Image string `yaml:"image"`
+	// Version is the tag of the image in use.
+	Version string `yaml:"version"`
+
+	// Tags filters the tags of the image and extracts their versions. If
+	// unset, only tags formatted like Version are considered: tags must have
+	// the same suffix and number of version components, so 3.19.0-alpine is
+	// only updated to other tags like 3.20.1-alpine.
+	Tags TagFilter `yaml:",inline"`
+
+	Options DependencyOptions `yaml:",inline"`
+
+	// File and Line are where the image is referenced in the repository.
+	// They are only set for discovered images, and are reported in UsedBy.
+	File string `yaml:"-"`
+	Line int    `yaml:"-"`
+}
+
+// UnmarshalYAML will unmarshal a string or an object into a ContainerImage.
+func (d *ContainerImage) UnmarshalYAML(f func(interface{}) error) error {
+	var (
+		stringError error
+		objectError error
+	)
+
+	// Try as a raw string
+	var s string
+	stringError = f(&s)
+	if stringError == nil {
+		image, tag := splitImageTag(s)
+		if tag == "" {
+			return fmt.Errorf("invalid image %s: expected format '[image]:[tag]'", s)
+		}
+		d.Image, d.Version = image, tag
+		return nil
+	}
+
+	// Then a whole object
+	type containerImage ContainerImage
+	var v containerImage
+	objectError = f(&v)
+	if objectError == nil {
+		*d = ContainerImage(v)
+		return nil
+	}
+
+	return fmt.Errorf(
+		"could not parse container image as a string (%s) or an object (%s)",
+		stringError,
+		objectError,
+	)
+}
+
+// NewContainerImages creates a new ContainerImages tracker. Registries are
+// authenticated using credentials from the docker config file.
+func NewContainerImages(check []ContainerImage) *ContainerImages {
+	return &ContainerImages{check: check, registry: newRegistryClient(), tags: make(map[imageRef][]string)}
+}
+
+// CheckOutdated will return the list of container images that can be
+// updated. Versions are reported as tags.
+func (c *ContainerImages) CheckOutdated(ctx context.Context) ([]Dependency, error) {
+	var outdated []Dependency
+
+	for _, d := range c.check {
+		latest, ok, err := c.checkImage(ctx, d)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", d.Image, err)
+		} else if ok {
+			outdated = append(outdated, latest)
+		}
+	}
+
+	return outdated, nil
+}
+
+// checkImage returns the newest update to the tag of an image, if any.
+func (c *ContainerImages) checkImage(ctx context.Context, d ContainerImage) (Dependency, bool, error) {
+	ref, err := parseImageRef(d.Image)
+	if err != nil {
+		return Dependency{}, false, err
+	}
+
+	filter, err := d.tagFilter()
+	if err != nil {
+		return Dependency{}, false, err
+	}
+	current, ok := filter.Version(d.Version)
+	if !ok {
+		return Dependency{}, false, fmt.Errorf("tag %s doesn't match the tag filter", d.Version)
+	}
+
+	tags, ok := c.tags[ref]
+	if !ok {
+		tags, err = c.registry.Tags(ctx, ref)
+		if err != nil {
+			return Dependency{}, false, fmt.Errorf("couldn't list tags: %w", err)
+		}
+		c.tags[ref] = tags
+	}
+
+	// Filter down to the tags of the same variant, and remember which tag
+	// each version came from.
+	var (
+		available []Dependency
+		tagNames  = make(map[string]string, len(tags))
+	)
+	for _, tag := range tags {
+		version, ok := filter.Version(tag)
+		if !ok {
+			continue
+		}
+		tagNames[version] = tag
+		available = append(available, Dependency{LatestVersion: version})
+	}
+	available, err = d.Options.sortReleases(available)
+	if err != nil {
+		return Dependency{}, false, err
+	} else if len(available) == 0 {
+		return Dependency{}, false, fmt.Errorf("no valid version tags found")
+	}
+
+	// Registries don't record when tags were pushed, so there is no
+	// publish time to check against.
+	latest, ok, err := d.Options.newestUpdate(current, available, nil)
+	if err != nil || !ok {
+		return Dependency{}, false, err
+	}
+	latest.Name = d.Image
+	if variant := d.variant(); variant != "" {
+		latest.Name += " (" + variant + ")"
+	}
+	latest.CurrentVersion = d.Version
+	latest.LatestVersion = tagNames[latest.LatestVersion]
+	if d.File != "" {
+		latest.UsedBy = []string{fmt.Sprintf("%s:%d", d.File, d.Line)}
+	}
+	return latest, true, nil
+}
+
+// tagFilter returns the filter for tags of d. Unless d sets one, tags must
+// be formatted like the current tag, which excludes other variants and
+// floating tags such as 3.19 or latest.
+func (d *ContainerImage) tagFilter() (TagFilter, error) {
+	if d.Tags.TagPrefix != "" || d.Tags.TagPattern != nil {
+		return d.Tags, nil
+	}
+
+	m := containerTagRegexp.FindStringSubmatch(d.Version)
+	if m == nil {
+		return TagFilter{}, fmt.Errorf("tag %q doesn't start with a version; set tag_pattern to extract its version", d.Version)
+	}
+	var (
+		prefix     = m[1]
+		components = strings.Count(m[2], ".") + 1
+		suffix     = m[3]
+	)
+	re := regexp.MustCompile(fmt.Sprintf(`^(?P<version>%s\d+(?:\.\d+){%d})%s$`, prefix, components-1, regexp.QuoteMeta(suffix)))
+	return TagFilter{TagPattern: (*Regexp)(re)}, nil
+}
+
+// variant describes which tags of the image are considered, so that
+// variants of one image such as 1.2.0 and 1.2.0-alpine are reported under
+// different names. It is the tag filter when d sets one, and otherwise the
+// suffix of the current tag. It is empty for tags without a suffix.
+func (d *ContainerImage) variant() string {
+	switch {
+	case d.Tags.TagPrefix != "" && d.Tags.TagPattern != nil:
+		return d.Tags.TagPrefix + " " + (*regexp.Regexp)(d.Tags.TagPattern).String()
+	case d.Tags.TagPrefix != "":
+		return d.Tags.TagPrefix
+	case d.Tags.TagPattern != nil:
+		return (*regexp.Regexp)(d.Tags.TagPattern).String()
+	}
+
+	if m := containerTagRegexp.FindStringSubmatch(d.Version); m != nil {
+		return m[3]
+	}
+	return ""
+}
@@ -0,0 +1,146 @@
+package tracker
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+func TestParseContainerImage(t *testing.T) {
+	tt := []struct {
+		input  string
+		expect ContainerImage
+	}{
+		{
+			input:  `"grafana/grafana:10.2.0"`,
+			expect: ContainerImage{Image: "grafana/grafana", Version: "10.2.0"},
+		},
+		{
+			input:  `"localhost:5000/app:v1.0.0"`,
+			expect: ContainerImage{Image: "localhost:5000/app", Version: "v1.0.0"},
+		},
+		{
+			input: `{
+				"image": "ghcr.io/grafana/agent",
+				"version": "v0.40.0",
+				"update_policy": "minor",
+			}`,
+			expect: ContainerImage{
+				Image:   "ghcr.io/grafana/agent",
+				Version: "v0.40.0",
+				Options: DependencyOptions{UpdatePolicy: UpdatePolicyMinor},
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		var actual ContainerImage
+		err := yaml.Unmarshal([]byte(tc.input), &actual)
+		require.NoError(t, err)
+		require.Equal(t, tc.expect, actual)
+	}
+
+	var actual ContainerImage
+	err := yaml.Unmarshal([]byte(`"localhost:5000/app"`), &actual)
+	require.EqualError(t, err, "invalid image localhost:5000/app: expected format '[image]:[tag]'")
+}
+
+func TestParseImageRef(t *testing.T) {
+	tt := []struct {
+		image  string
+		expect imageRef
+	}{
+		{image: "alpine", expect: imageRef{Registry: "registry-1.docker.io", Repository: "library/alpine"}},
+		{image: "docker.io/grafana/grafana", expect: imageRef{Registry: "registry-1.docker.io", Repository: "grafana/grafana"}},
+		{image: "ghcr.io/grafana/agent", expect: imageRef{Registry: "ghcr.io", Repository: "grafana/agent"}},
+		{image: "localhost:5000/team/app", expect: imageRef{Registry: "localhost:5000", Repository: "team/app"}},
+	}
+
+	for _, tc := range tt {
+		actual, err := parseImageRef(tc.image)
+		require.NoError(t, err)
+		require.Equal(t, tc.expect, actual, tc.image)
+	}
+}
+
+func TestContainerImages_CheckOutdated(t *testing.T) {
+	// Credentials for the registry are read from the docker config.
+	auth := base64.StdEncoding.EncodeToString([]byte("user:secret"))
+
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	registry := strings.TrimPrefix(srv.URL, "http://")
+
+	// Token requests are recorded and checked once CheckOutdated returns,
+	// since tests can't fail from handler goroutines.
+	var (
+		mut           sync.Mutex
+		authorization []string
+		scopes        []string
+	)
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		mut.Lock()
+		authorization = append(authorization, r.Header.Get("Authorization"))
+		scopes = append(scopes, r.URL.Query().Get("scope"))
+		mut.Unlock()
+
+		if r.Header.Get("Authorization") != "Basic "+auth {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"token": "registry-token"}`)
+	})
+	mux.HandleFunc("/v2/team/app/tags/list", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer registry-token" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test",scope="repository:team/app:pull"`, srv.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Query().Get("last") == "" {
+			w.Header().Set("Link", `</v2/team/app/tags/list?last=1.3.0&n=1000>; rel="next"`)
+			fmt.Fprint(w, `{"name": "team/app", "tags": ["1.2.0", "1.2.0-alpine", "1.3.0"]}`)
+			return
+		}
+		fmt.Fprint(w, `{"name": "team/app", "tags": ["1.3", "1.4.0-alpine", "2.0.0-rc.1", "latest"]}`)
+	})
+
+	dockerConfig := t.TempDir()
+	err := os.WriteFile(filepath.Join(dockerConfig, "config.json"), []byte(fmt.Sprintf(`{"auths": {%q: {"auth": %q}}}`, registry, auth)), 0644)
+	require.NoError(t, err)
+	t.Setenv("DOCKER_CONFIG", dockerConfig)
+
+	c := NewContainerImages([]ContainerImage{
+		{Image: registry + "/team/app", Version: "1.2.0"},
+		{Image: registry + "/team/app", Version: "1.2.0-alpine"},
+		{Image: registry + "/team/app", Version: "1.2.0", Tags: TagFilter{TagPattern: (*Regexp)(regexp.MustCompile(`^(?P<version>[\d.]+(-rc\.\d+)?)$`))}},
+	})
+	deps, err := c.CheckOutdated(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []Dependency{
+		{Name: registry + "/team/app", CurrentVersion: "1.2.0", LatestVersion: "1.3.0"},
+		// Variants are named differently so their issues don't replace each
+		// other.
+		{Name: registry + "/team/app (-alpine)", CurrentVersion: "1.2.0-alpine", LatestVersion: "1.4.0-alpine"},
+		{Name: registry + `/team/app (^(?P<version>[\d.]+(-rc\.\d+)?)$)`, CurrentVersion: "1.2.0", LatestVersion: "2.0.0-rc.1"},
+	}, deps)
+
+	mut.Lock()
+	defer mut.Unlock()
+	require.NotEmpty(t, authorization)
+	for i := range authorization {
+		require.Equal(t, "Basic "+auth, authorization[i])
+		require.Equal(t, "repository:team/app:pull", scopes[i])
+	}
+}
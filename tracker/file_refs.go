@@ -0,0 +1,124 @@
+package tracker
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// FileRef locates the current version of a dependency within files of the
+// repository, so it doesn't need to be maintained in the config.
+type FileRef struct {
+	// Path is a glob of files relative to the repository, such as
+	// "Makefile" or "scripts/*.sh".
+	Path string `yaml:"path"`
+	// Pattern matches the version in the files. The version is captured by
+	// the capture group named "version".
+	Pattern *Regexp `yaml:"pattern"`
+}
+
+// UnmarshalYAML unmarshals a FileRef, ensuring that it is valid.
+func (r *FileRef) UnmarshalYAML(f func(v interface{}) error) error {
+	type fileRef FileRef
+	if err := f((*fileRef)(r)); err != nil {
+		return err
+	}
+
+	switch {
+	case r.Path == "":
+		return fmt.Errorf("file_refs: path must be set")
+	case r.Pattern == nil:
+		return fmt.Errorf("file_refs: pattern must be set")
+	case (*regexp.Regexp)(r.Pattern).SubexpIndex("version") < 0:
+		return fmt.Errorf("file_refs: pattern %q must have a capture group named \"version\"", (*regexp.Regexp)(r.Pattern))
+	}
+	if _, err := filepath.Match(r.Path, ""); err != nil {
+		return fmt.Errorf("file_refs: invalid path %q: %w", r.Path, err)
+	}
+	return nil
+}
+
+// fileRefMatch is a match of a FileRef.
+type fileRefMatch struct {
+	// Location is the file and line of the match, such as "Makefile:12".
+	Location string
+	Version  string
+}
+
+// currentVersion returns the current version of a dependency. If refs is
+// empty, version is returned. Otherwise, the version is read from the files
+// in repo referenced by refs. Each FileRef must match exactly once, and all
+// of them must match the same version.
+func currentVersion(repo, version string, refs []FileRef) (string, error) {
+	if len(refs) == 0 {
+		return version, nil
+	} else if version != "" {
+		return "", fmt.Errorf("version and file_refs can't both be set")
+	}
+
+	var found []fileRefMatch
+	for _, ref := range refs {
+		matches, err := ref.find(repo)
+		if err != nil {
+			return "", err
+		}
+
+		switch len(matches) {
+		case 0:
+			return "", fmt.Errorf("file_refs: pattern %q doesn't match %s", (*regexp.Regexp)(ref.Pattern), ref.Path)
+		case 1:
+			found = append(found, matches[0])
+		default:
+			locations := make([]string, len(matches))
+			for i, m := range matches {
+				locations[i] = m.Location
+			}
+			return "", fmt.Errorf("file_refs: pattern %q matches %d times (%s); it must match exactly once", (*regexp.Regexp)(ref.Pattern), len(matches), strings.Join(locations, ", "))
+		}
+	}
+
+	for _, m := range found[1:] {
+		if m.Version != found[0].Version {
+			return "", fmt.Errorf("file_refs: versions don't agree: %s in %s, but %s in %s", found[0].Version, found[0].Location, m.Version, m.Location)
+		}
+	}
+	return found[0].Version, nil
+}
+
+// find returns all matches of r in the files of repo.
+func (r *FileRef) find(repo string) ([]fileRefMatch, error) {
+	files, err := filepath.Glob(filepath.Join(repo, r.Path))
+	if err != nil {
+		return nil, fmt.Errorf("file_refs: invalid path %q: %w", r.Path, err)
+	} else if len(files) == 0 {
+		return nil, fmt.Errorf("file_refs: %s doesn't match any files", r.Path)
+	}
+
+	var (
+		matches []fileRefMatch
+		re      = (*regexp.Regexp)(r.Pattern)
+		group   = re.SubexpIndex("version")
+	)
+	for _, file := range files {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("file_refs: %w", err)
+		}
+		rel, err := filepath.Rel(repo, file)
+		if err != nil {
+			rel = file
+		}
+
+		for _, loc := range re.FindAllSubmatchIndex(b, -1) {
+			line := bytes.Count(b[:loc[0]], []byte("\n")) + 1
+			matches = append(matches, fileRefMatch{
+				Location: fmt.Sprintf("%s:%d", filepath.ToSlash(rel), line),
+				Version:  string(b[loc[2*group]:loc[2*group+1]]),
+			})
+		}
+	}
+	return matches, nil
+}
@@ -0,0 +1,76 @@
+package tracker
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+func TestParseFileRef_Invalid(t *testing.T) {
+	tt := []struct {
+		input  string
+		expect string
+	}{
+		{input: `{"pattern": "v(?P<version>.+)"}`, expect: "file_refs: path must be set"},
+		{input: `{"path": "Makefile"}`, expect: "file_refs: pattern must be set"},
+		{input: `{"path": "Makefile", "pattern": "v(.+)"}`, expect: `file_refs: pattern "v(.+)" must have a capture group named "version"`},
+	}
+
+	for _, tc := range tt {
+		var actual FileRef
+		err := yaml.Unmarshal([]byte(tc.input), &actual)
+		require.EqualError(t, err, tc.expect)
+	}
+}
+
+func TestCurrentVersion(t *testing.T) {
+	repo := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(repo, "scripts"), 0755))
+	files := map[string]string{
+		"Makefile":           "BUILD_IMAGE_VERSION ?= v0.20.1\nAGENT_VERSION ?= v0.30.0\n",
+		"scripts/install.sh": "#!/bin/sh\nAGENT_VERSION=v0.30.0\n",
+		"scripts/upgrade.sh": "#!/bin/sh\nAGENT_VERSION=v0.31.0\n",
+	}
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(repo, name), []byte(content), 0644))
+	}
+
+	ref := func(path, pattern string) FileRef {
+		var r FileRef
+		input := "{path: '" + path + "', pattern: '" + pattern + "'}"
+		require.NoError(t, yaml.Unmarshal([]byte(input), &r))
+		return r
+	}
+
+	version, err := currentVersion(repo, "v1.0.0", nil)
+	require.NoError(t, err)
+	require.Equal(t, "v1.0.0", version)
+
+	version, err = currentVersion(repo, "", []FileRef{
+		ref("Makefile", `AGENT_VERSION \?= (?P<version>\S+)`),
+		ref("scripts/install.sh", `AGENT_VERSION=(?P<version>\S+)`),
+	})
+	require.NoError(t, err)
+	require.Equal(t, "v0.30.0", version)
+
+	_, err = currentVersion(repo, "v1.0.0", []FileRef{ref("Makefile", `AGENT_VERSION \?= (?P<version>\S+)`)})
+	require.EqualError(t, err, "version and file_refs can't both be set")
+
+	_, err = currentVersion(repo, "", []FileRef{ref("Makefile", `LOKI_VERSION \?= (?P<version>\S+)`)})
+	require.EqualError(t, err, `file_refs: pattern "LOKI_VERSION \\?= (?P<version>\\S+)" doesn't match Makefile`)
+
+	_, err = currentVersion(repo, "", []FileRef{ref("scripts/*.sh", `AGENT_VERSION=(?P<version>\S+)`)})
+	require.EqualError(t, err, `file_refs: pattern "AGENT_VERSION=(?P<version>\\S+)" matches 2 times (scripts/install.sh:2, scripts/upgrade.sh:2); it must match exactly once`)
+
+	_, err = currentVersion(repo, "", []FileRef{
+		ref("Makefile", `AGENT_VERSION \?= (?P<version>\S+)`),
+		ref("scripts/upgrade.sh", `AGENT_VERSION=(?P<version>\S+)`),
+	})
+	require.EqualError(t, err, "file_refs: versions don't agree: v0.30.0 in Makefile:2, but v0.31.0 in scripts/upgrade.sh:2")
+
+	_, err = currentVersion(repo, "", []FileRef{ref("*.yaml", `(?P<version>v\S+)`)})
+	require.EqualError(t, err, "file_refs: *.yaml doesn't match any files")
+}
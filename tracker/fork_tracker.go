@@ -0,0 +1,196 @@
+package tracker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-github/v48/github"
+)
+
+// Forks checks whether forks of Github projects are behind their upstream.
+type Forks struct {
+	check []ForkDependency
+	cli   *github.Client
+}
+
+// ForkDependency is a fork of an upstream Github project.
+type ForkDependency struct {
+	// Fork is the repository of the fork, such as grafana/prometheus.
+	Fork string `yaml:"fork"`
+	// Branch is the branch of the fork which changes from upstream are
+	// merged into. Defaults to the default branch of the fork.
+	Branch string `yaml:"branch"`
+	// Base is the upstream commit the fork was last synced with. When set,
+	// upstream changes are compared against Base rather than Branch, which
+	// is needed if the fork is rebased or isn't a Github fork of Upstream.
+	Base string `yaml:"base"`
+
+	// Upstream is the repository the fork was created from, such as
+	// prometheus/prometheus.
+	Upstream string `yaml:"upstream"`
+	// UpstreamBranch is the branch of Upstream to follow. Defaults to the
+	// default branch of Upstream.
+	UpstreamBranch string `yaml:"upstream_branch"`
+
+	// Version is the upstream release the fork is based on. When set,
+	// upstream tags newer than Version are reported until they are merged
+	// into the fork.
+	Version string `yaml:"version"`
+
+	// Tags filters upstream tags to a single component of the repository.
+	// Version is then the version of that component.
+	Tags TagFilter `yaml:",inline"`
+
+	Options DependencyOptions `yaml:",inline"`
+}
+
+// NewForks creates a new Forks tracker.
+func NewForks(check []ForkDependency, cli *github.Client) *Forks {
+	return &Forks{check: check, cli: cli}
+}
+
+// CheckOutdated will return forks which are behind their upstream. Each fork
+// is reported as "github.com/owner/repo@branch" with kind UpdateKindCommits
+// when the upstream branch has commits which aren't in the fork, and as
+// "github.com/owner/repo" when Version is set and a newer upstream release
+// hasn't been merged into the fork.
+func (c *Forks) CheckOutdated(ctx context.Context) ([]Dependency, error) {
+	var outdated []Dependency
+
+	for _, d := range c.check {
+		forkOwner, forkRepo, err := parseGithubRepo(strings.TrimPrefix(d.Fork, "github.com/"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid fork: %w", err)
+		}
+		upstreamOwner, upstreamRepo, err := parseGithubRepo(strings.TrimPrefix(d.Upstream, "github.com/"))
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid upstream: %w", d.Fork, err)
+		}
+
+		// Upstream changes are compared against the fork branch across
+		// repositories, or against the last synced commit if one is known.
+		base := d.Base
+		if base == "" {
+			branch := d.Branch
+			if branch == "" {
+				r, _, err := c.cli.Repositories.Get(ctx, forkOwner, forkRepo)
+				if err != nil {
+					return nil, fmt.Errorf("%s: couldn't get repository: %w", d.Fork, err)
+				}
+				branch = r.GetDefaultBranch()
+			}
+			base = forkOwner + ":" + forkRepo + ":" + branch
+		}
+
+		upstreamBranch := d.UpstreamBranch
+		if upstreamBranch == "" {
+			r, _, err := c.cli.Repositories.Get(ctx, upstreamOwner, upstreamRepo)
+			if err != nil {
+				return nil, fmt.Errorf("%s: couldn't get upstream repository: %w", d.Fork, err)
+			}
+			upstreamBranch = r.GetDefaultBranch()
+		}
+
+		name := "github.com/" + upstreamOwner + "/" + upstreamRepo
+
+		cmp, _, err := c.cli.Repositories.CompareCommits(ctx, upstreamOwner, upstreamRepo, base, upstreamBranch, &github.ListOptions{PerPage: githubPageSize})
+		if err != nil {
+			return nil, fmt.Errorf("%s: couldn't compare to %s: %w", d.Fork, upstreamBranch, err)
+		}
+		if cmp.GetAheadBy() > 0 {
+			dep := Dependency{
+				Name:           name + "@" + upstreamBranch,
+				Kind:           UpdateKindCommits,
+				CurrentVersion: shortHash(cmp.GetMergeBaseCommit().GetSHA()),
+				CommitsAhead:   cmp.GetAheadBy(),
+				CompareURL:     cmp.GetHTMLURL(),
+				Commits:        forkCommits(cmp.Commits),
+			}
+			// Commits are listed from oldest to newest, but only the first
+			// page is returned, so the newest commit is taken from the
+			// upstream branch.
+			head, _, err := c.cli.Repositories.GetBranch(ctx, upstreamOwner, upstreamRepo, upstreamBranch, false)
+			if err != nil {
+				return nil, fmt.Errorf("%s: couldn't get upstream branch: %w", d.Fork, err)
+			}
+			dep.LatestVersion = shortHash(head.GetCommit().GetSHA())
+			dep.PublishedAt = head.GetCommit().GetCommit().GetCommitter().GetDate()
+			outdated = append(outdated, dep)
+		}
+
+		if d.Version == "" {
+			continue
+		}
+		release, tag, ok, err := c.newestRelease(ctx, upstreamOwner, upstreamRepo, d)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", d.Fork, err)
+		} else if !ok {
+			continue
+		}
+
+		// The release may already be merged even if Version wasn't updated.
+		cmp, _, err = c.cli.Repositories.CompareCommits(ctx, upstreamOwner, upstreamRepo, tag, base, &github.ListOptions{PerPage: 1})
+		if err != nil {
+			return nil, fmt.Errorf("%s: couldn't compare to %s: %w", d.Fork, tag, err)
+		}
+		switch cmp.GetStatus() {
+		case "ahead", "identical":
+			continue
+		}
+
+		release.Name = name
+		if component := d.Tags.Component(); component != "" {
+			release.Name += "/" + component
+		}
+		release.CurrentVersion = d.Version
+		outdated = append(outdated, release)
+	}
+
+	return outdated, nil
+}
+
+// newestRelease returns the newest upstream release which is an update to
+// d.Version, along with its tag.
+func (c *Forks) newestRelease(ctx context.Context, owner, repo string, d ForkDependency) (release Dependency, tag string, ok bool, err error) {
+	gh := &Github{cli: c.cli}
+	tags, err := gh.listTags(ctx, owner, repo)
+	if err != nil {
+		return Dependency{}, "", false, err
+	}
+
+	var (
+		available []Dependency
+		tagNames  = make(map[string]string, len(tags))
+	)
+	for _, t := range tags {
+		if version, ok := d.Tags.Version(t.LatestVersion); ok {
+			tagNames[version] = t.LatestVersion
+			available = append(available, Dependency{LatestVersion: version})
+		}
+	}
+	available, err = d.Options.sortReleases(available)
+	if err != nil {
+		return Dependency{}, "", false, err
+	} else if len(available) == 0 {
+		return Dependency{}, "", false, fmt.Errorf("no valid version tags found in %s/%s", owner, repo)
+	}
+
+	release, ok, err = d.Options.newestUpdate(d.Version, available, gh.tagPublished(ctx, owner, repo, tagNames))
+	return release, tagNames[release.LatestVersion], ok, err
+}
+
+// forkCommits returns the summaries of commits from a comparison.
+func forkCommits(commits []*github.RepositoryCommit) []Commit {
+	res := make([]Commit, 0, len(commits))
+	for _, c := range commits {
+		summary, _, _ := strings.Cut(c.GetCommit().GetMessage(), "\n")
+		res = append(res, Commit{
+			SHA:     c.GetSHA(),
+			Summary: summary,
+			Author:  c.GetCommit().GetAuthor().GetName(),
+			URL:     c.GetHTMLURL(),
+		})
+	}
+	return res
+}
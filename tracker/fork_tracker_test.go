@@ -0,0 +1,108 @@
+package tracker
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestForks_CheckOutdated(t *testing.T) {
+	const head = "0123456789abcdef0123456789abcdef01234567"
+	committed := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	cli := newTestGithubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/grafana/prometheus":
+			fmt.Fprint(w, `{"default_branch": "grafana-main"}`)
+		case "/repos/prometheus/prometheus/compare/grafana:prometheus:grafana-main...main":
+			fmt.Fprint(w, `{
+				"status": "diverged",
+				"ahead_by": 2,
+				"html_url": "https://github.com/prometheus/prometheus/compare/grafana:prometheus:grafana-main...main",
+				"merge_base_commit": {"sha": "fedcba9876543210fedcba9876543210fedcba98"},
+				"commits": [
+					{"sha": "1111111", "html_url": "https://github.com/prometheus/prometheus/commit/1111111", "commit": {"message": "Fix TSDB compaction\n\nDetails.", "author": {"name": "Ada"}}},
+					{"sha": "2222222", "html_url": "https://github.com/prometheus/prometheus/commit/2222222", "commit": {"message": "Update dependencies", "author": {"name": "Grace"}}}
+				]
+			}`)
+		case "/repos/prometheus/prometheus/branches/main":
+			fmt.Fprintf(w, `{"commit": {"sha": %q, "commit": {"committer": {"date": %q}}}}`, head, committed.Format(time.RFC3339))
+		case "/repos/prometheus/prometheus/tags":
+			fmt.Fprint(w, `[{"name": "v2.51.0"}, {"name": "v2.50.0"}, {"name": "v2.49.0"}]`)
+		case "/repos/prometheus/prometheus/compare/v2.51.0...grafana:prometheus:grafana-main":
+			fmt.Fprint(w, `{"status": "behind"}`)
+		case "/repos/prometheus/prometheus/compare/v2.51.0...grafana:prometheus:release-2.51":
+			fmt.Fprint(w, `{"status": "ahead"}`)
+		case "/repos/prometheus/prometheus/compare/grafana:prometheus:release-2.51...main":
+			fmt.Fprint(w, `{"status": "ahead", "ahead_by": 0}`)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	f := NewForks([]ForkDependency{
+		{Fork: "grafana/prometheus", Upstream: "github.com/prometheus/prometheus", UpstreamBranch: "main", Version: "v2.49.0"},
+		{Fork: "grafana/prometheus", Branch: "release-2.51", Upstream: "prometheus/prometheus", UpstreamBranch: "main", Version: "v2.49.0"},
+	}, cli)
+	deps, err := f.CheckOutdated(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []Dependency{
+		{
+			Name:           "github.com/prometheus/prometheus@main",
+			Kind:           UpdateKindCommits,
+			CurrentVersion: "fedcba9",
+			LatestVersion:  "0123456",
+			PublishedAt:    committed,
+			CommitsAhead:   2,
+			CompareURL:     "https://github.com/prometheus/prometheus/compare/grafana:prometheus:grafana-main...main",
+			Commits: []Commit{
+				{SHA: "1111111", Summary: "Fix TSDB compaction", Author: "Ada", URL: "https://github.com/prometheus/prometheus/commit/1111111"},
+				{SHA: "2222222", Summary: "Update dependencies", Author: "Grace", URL: "https://github.com/prometheus/prometheus/commit/2222222"},
+			},
+		},
+		{Name: "github.com/prometheus/prometheus", CurrentVersion: "v2.49.0", LatestVersion: "v2.51.0"},
+	}, deps)
+}
+
+func TestForks_CheckOutdated_MinAge(t *testing.T) {
+	var (
+		recent = time.Now().Add(-time.Hour)
+		old    = time.Now().Add(-30 * 24 * time.Hour)
+	)
+
+	cli := newTestGithubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/prometheus/prometheus/compare/grafana:prometheus:main...main":
+			fmt.Fprint(w, `{"status": "behind", "ahead_by": 0}`)
+		case "/repos/prometheus/prometheus/tags":
+			fmt.Fprint(w, `[{"name": "v2.51.0"}, {"name": "v2.50.0"}, {"name": "v2.49.0"}]`)
+		case "/repos/prometheus/prometheus/commits/v2.51.0":
+			fmt.Fprintf(w, `{"commit": {"committer": {"date": %q}}}`, recent.Format(time.RFC3339))
+		case "/repos/prometheus/prometheus/commits/v2.50.0":
+			fmt.Fprintf(w, `{"commit": {"committer": {"date": %q}}}`, old.Format(time.RFC3339))
+		case "/repos/prometheus/prometheus/compare/v2.50.0...grafana:prometheus:main":
+			fmt.Fprint(w, `{"status": "behind"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	// Tags are only reported once their commit is older than min_age.
+	f := NewForks([]ForkDependency{{
+		Fork:           "grafana/prometheus",
+		Branch:         "main",
+		Upstream:       "prometheus/prometheus",
+		UpstreamBranch: "main",
+		Version:        "v2.49.0",
+		Options:        DependencyOptions{MinAge: 7 * 24 * time.Hour},
+	}}, cli)
+	deps, err := f.CheckOutdated(context.Background())
+	require.NoError(t, err)
+	require.Len(t, deps, 1)
+	require.Equal(t, "v2.50.0", deps[0].LatestVersion)
+	require.Equal(t, old.Unix(), deps[0].PublishedAt.Unix())
+}
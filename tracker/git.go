@@ -0,0 +1,113 @@
+package tracker
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// gitRefs are the references advertised by a git remote.
+type gitRefs struct {
+	// Head is the commit that HEAD points to, if advertised.
+	Head string
+	// Branches maps branch names to the commit they point to.
+	Branches map[string]string
+	// Tags maps tag names to the commit they point to. Annotated tags are
+	// peeled to the commit they reference.
+	Tags map[string]string
+}
+
+// TagNames returns the sorted list of tag names.
+func (r *gitRefs) TagNames() []string {
+	names := make([]string, 0, len(r.Tags))
+	for name := range r.Tags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// lsRemote lists the references of remote by running "git ls-remote".
+func lsRemote(ctx context.Context, remote string) (*gitRefs, error) {
+	var out, stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, "git", "ls-remote", remote)
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	// Never prompt for credentials; fail instead.
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed running git command %q: %w: %s", cmd, err, strings.TrimSpace(stderr.String()))
+	}
+	return parseLsRemote(out.Bytes())
+}
+
+func parseLsRemote(b []byte) (*gitRefs, error) {
+	refs := &gitRefs{
+		Branches: make(map[string]string),
+		Tags:     make(map[string]string),
+	}
+	peeled := make(map[string]string)
+
+	s := bufio.NewScanner(bytes.NewReader(b))
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" {
+			continue
+		}
+		hash, ref, ok := strings.Cut(line, "\t")
+		if !ok {
+			return nil, fmt.Errorf("unexpected git ls-remote output %q", line)
+		}
+
+		switch {
+		case ref == "HEAD":
+			refs.Head = hash
+		case strings.HasPrefix(ref, "refs/heads/"):
+			refs.Branches[strings.TrimPrefix(ref, "refs/heads/")] = hash
+		case strings.HasPrefix(ref, "refs/tags/") && strings.HasSuffix(ref, "^{}"):
+			peeled[strings.TrimSuffix(strings.TrimPrefix(ref, "refs/tags/"), "^{}")] = hash
+		case strings.HasPrefix(ref, "refs/tags/"):
+			refs.Tags[strings.TrimPrefix(ref, "refs/tags/")] = hash
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	for tag, hash := range peeled {
+		refs.Tags[tag] = hash
+	}
+	return refs, nil
+}
+
+// shortHash shortens a commit hash for display.
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
+
+// remoteName returns a display name for a git remote URL without its scheme,
+// credentials, or ".git" suffix, e.g., github.com/grafana/jsonnet-libs for
+// git@github.com:grafana/jsonnet-libs.git.
+func remoteName(remote string) string {
+	name := remote
+	if i := strings.Index(name, "://"); i >= 0 {
+		name = name[i+len("://"):]
+	} else if user, rest, ok := strings.Cut(name, "@"); ok && !strings.Contains(user, "/") {
+		// scp-like syntax, e.g., git@github.com:grafana/jsonnet-libs.git
+		name = strings.Replace(rest, ":", "/", 1)
+	}
+	if _, host, ok := strings.Cut(name, "@"); ok {
+		name = host
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(name, "/"), ".git")
+}
@@ -0,0 +1,121 @@
+package tracker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Git checks for outdated dependencies on any git repository by listing its
+// tags with "git ls-remote".
+type Git struct {
+	repo  string
+	check []GitDependency
+}
+
+// GitDependency is a dependency on a git repository.
+type GitDependency struct {
+	// Remote is the URL of the repository, as accepted by "git clone".
+	Remote  string `yaml:"remote"`
+	Version string `yaml:"version"`
+
+	// FileRefs reads Version from files in the repository instead of the
+	// config.
+	FileRefs []FileRef `yaml:"file_refs"`
+
+	// Tags filters tags to a single component of the repository. Version
+	// is then the version of that component.
+	Tags TagFilter `yaml:",inline"`
+
+	Options DependencyOptions `yaml:",inline"`
+}
+
+// UnmarshalYAML will unmarshal a string or an object into a GitDependency.
+func (d *GitDependency) UnmarshalYAML(f func(interface{}) error) error {
+	var (
+		stringError error
+		objectError error
+	)
+
+	// Try as a raw string
+	var s string
+	stringError = f(&s)
+	if stringError == nil {
+		parts := strings.Fields(s)
+		if len(parts) != 2 {
+			return fmt.Errorf("invalid dependency %s: expected format '[remote] [version]'", s)
+		}
+		d.Remote, d.Version = parts[0], parts[1]
+		return nil
+	}
+
+	// Then a whole object
+	type gitDependency GitDependency
+	var v gitDependency
+	objectError = f(&v)
+	if objectError == nil {
+		*d = GitDependency(v)
+		return nil
+	}
+
+	return fmt.Errorf(
+		"could not parse git dependency as a string (%s) or an object (%s)",
+		stringError,
+		objectError,
+	)
+}
+
+// NewGit creates a new Git tracker. File references of dependencies are
+// relative to repo.
+func NewGit(repo string, check []GitDependency) *Git {
+	return &Git{repo: repo, check: check}
+}
+
+// CheckOutdated will return the list of git dependencies that can be updated.
+func (c *Git) CheckOutdated(ctx context.Context) ([]Dependency, error) {
+	var outdated []Dependency
+
+	for _, d := range c.check {
+		name := remoteName(d.Remote)
+		if component := d.Tags.Component(); component != "" {
+			name += "/" + component
+		}
+
+		version, err := currentVersion(c.repo, d.Version, d.FileRefs)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		d.Version = version
+
+		refs, err := lsRemote(ctx, d.Remote)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't list refs for %s: %w", name, err)
+		}
+
+		var available []Dependency
+		for _, tag := range refs.TagNames() {
+			if version, ok := d.Tags.Version(tag); ok {
+				available = append(available, Dependency{LatestVersion: version})
+			}
+		}
+		available, err = d.Options.sortReleases(available)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		} else if len(available) == 0 {
+			return nil, fmt.Errorf("%s: no valid version tags found", name)
+		}
+
+		// git doesn't advertise when tags were created, so there is no
+		// publish time to check against.
+		latest, ok, err := d.Options.newestUpdate(d.Version, available, nil)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		} else if ok {
+			latest.Name = name
+			latest.CurrentVersion = d.Version
+			outdated = append(outdated, latest)
+		}
+	}
+
+	return outdated, nil
+}
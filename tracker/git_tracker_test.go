@@ -0,0 +1,90 @@
+package tracker
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+func TestParseGitDependency(t *testing.T) {
+	tt := []struct {
+		input  string
+		expect GitDependency
+	}{
+		{
+			input:  `"https://gitlab.com/gitlab-org/gitlab-runner.git v16.0.0"`,
+			expect: GitDependency{Remote: "https://gitlab.com/gitlab-org/gitlab-runner.git", Version: "v16.0.0"},
+		},
+		{
+			input: `{
+				"remote": "https://git.sr.ht/~sircmpwn/hare",
+				"version": "0.24.0",
+				"tag_prefix": "v",
+				"update_policy": "minor",
+			}`,
+			expect: GitDependency{
+				Remote:  "https://git.sr.ht/~sircmpwn/hare",
+				Version: "0.24.0",
+				Tags:    TagFilter{TagPrefix: "v"},
+				Options: DependencyOptions{UpdatePolicy: UpdatePolicyMinor},
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		var actual GitDependency
+		err := yaml.Unmarshal([]byte(tc.input), &actual)
+		require.NoError(t, err)
+		require.Equal(t, tc.expect, actual)
+	}
+}
+
+func TestGit_CheckOutdated(t *testing.T) {
+	remote := newTestGitRemote(t, "v1.0.0", "v1.1.0", "v2.0.0-rc.1", "nightly", "operator/v0.2.0")
+
+	g := NewGit(t.TempDir(), []GitDependency{
+		{Remote: remote, Version: "v1.0.0"},
+		{Remote: remote, Version: "v0.1.0", Tags: TagFilter{TagPrefix: "operator/"}},
+		{Remote: remote, Version: "v1.1.0", Options: DependencyOptions{Ignore: mustParseConstraint(t, ">= 2.0.0-0")}},
+	})
+	deps, err := g.CheckOutdated(context.Background())
+	require.NoError(t, err)
+
+	name := remoteName(remote)
+	require.Equal(t, []Dependency{
+		{Name: name, CurrentVersion: "v1.0.0", LatestVersion: "v2.0.0-rc.1"},
+		{Name: name + "/operator", CurrentVersion: "v0.1.0", LatestVersion: "v0.2.0"},
+	}, deps)
+}
+
+// newTestGitRemote creates a bare git repository with the given tags and
+// returns its path.
+func newTestGitRemote(t *testing.T, tags ...string) string {
+	t.Helper()
+
+	var (
+		remote = filepath.Join(t.TempDir(), "remote.git")
+		work   = t.TempDir()
+	)
+	runGit(t, "", "init", "--bare", remote)
+	runGit(t, work, "init")
+	runGit(t, work, "-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--allow-empty", "-m", "initial")
+	for _, tag := range tags {
+		runGit(t, work, "tag", tag)
+	}
+	runGit(t, work, "push", "--tags", remote, "HEAD:refs/heads/main")
+	return remote
+}
+
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, "git %v: %s", args, out)
+}
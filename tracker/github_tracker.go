@@ -3,25 +3,86 @@ package tracker
 import (
 	"context"
 	"fmt"
+	"log"
 	"strings"
+	"time"
 
 	"github.com/google/go-github/v48/github"
-	"golang.org/x/mod/semver"
+)
+
+const (
+	// githubPageSize is the number of items requested per page from the
+	// Github API.
+	githubPageSize = 100
+	// githubMaxPages caps the number of pages of tags or releases read for a
+	// single dependency.
+	githubMaxPages = 10
 )
 
 // Github checks for outdated dependencies on Github projects.
 type Github struct {
+	repo  string
 	check []GithubDependency
 	cli   *github.Client
 }
 
 // GithubDependency is a dependency on a Github project.
 type GithubDependency struct {
-	Project string            `yaml:"project"`
-	Version string            `yaml:"version"`
+	Project string `yaml:"project"`
+	Version string `yaml:"version"`
+
+	// FileRefs reads Version from files in the repository instead of the
+	// config.
+	FileRefs []FileRef `yaml:"file_refs"`
+
+	// Branch pins the dependency to a commit on a branch rather than a tag.
+	// When set, Version is the SHA of the commit, which may be abbreviated,
+	// and the dependency is reported when the head of the branch advances.
+	Branch string `yaml:"branch"`
+	// ReportTags additionally reports the newest tag which contains or
+	// points to the pinned commit when Branch is set.
+	ReportTags bool `yaml:"report_tags"`
+
+	// Source is where available versions are read from. Defaults to
+	// GithubSourceTags.
+	Source GithubSource `yaml:"source"`
+	// IncludePrereleases allows prereleases to be reported when Source is
+	// GithubSourceReleases.
+	IncludePrereleases bool `yaml:"include_prereleases"`
+
+	// Tags filters tags to a single component of the repository. Version
+	// is then the version of that component.
+	Tags TagFilter `yaml:",inline"`
+
 	Options DependencyOptions `yaml:",inline"`
 }
 
+// GithubSource is a source of versions for a GithubDependency.
+type GithubSource string
+
+// Supported GithubSource values.
+const (
+	// GithubSourceTags reads versions from the repository tags.
+	GithubSourceTags GithubSource = "tags"
+	// GithubSourceReleases reads versions from published Github releases.
+	GithubSourceReleases GithubSource = "releases"
+)
+
+// UnmarshalYAML unmarshals a GithubSource, ensuring that it is valid.
+func (s *GithubSource) UnmarshalYAML(f func(v interface{}) error) error {
+	var str string
+	if err := f(&str); err != nil {
+		return err
+	}
+	switch v := GithubSource(str); v {
+	case GithubSourceTags, GithubSourceReleases:
+		*s = v
+		return nil
+	default:
+		return fmt.Errorf("invalid source %q: expected %q or %q", str, GithubSourceTags, GithubSourceReleases)
+	}
+}
+
 // UnmarshalYAML will unmarshal a string or an object into a GithubDependency.
 func (d *GithubDependency) UnmarshalYAML(f func(interface{}) error) error {
 	var (
@@ -62,9 +123,10 @@ func unmarshalGithubDependencyString(in string, dep *GithubDependency) error {
 	return nil
 }
 
-// NewGithub creates a new Github tracker.
-func NewGithub(check []GithubDependency, cli *github.Client) *Github {
-	return &Github{check: check, cli: cli}
+// NewGithub creates a new Github tracker. File references of dependencies
+// are relative to repo.
+func NewGithub(repo string, check []GithubDependency, cli *github.Client) *Github {
+	return &Github{repo: repo, check: check, cli: cli}
 }
 
 // CheckOutdated will return the list of go module dependencies that can be updated.
@@ -72,6 +134,12 @@ func (c *Github) CheckOutdated(ctx context.Context) ([]Dependency, error) {
 	var outdated []Dependency
 
 	for _, d := range c.check {
+		version, err := currentVersion(c.repo, d.Version, d.FileRefs)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", d.Project, err)
+		}
+		d.Version = version
+
 		// Trim out github.com/ from the name, but it'll be added back later.
 		sanitizedName := strings.TrimPrefix(d.Project, "github.com/")
 		nameParts := strings.SplitN(sanitizedName, "/", 2)
@@ -83,29 +151,247 @@ func (c *Github) CheckOutdated(ctx context.Context) ([]Dependency, error) {
 			repo  = nameParts[1]
 		)
 
-		tags, _, err := c.cli.Repositories.ListTags(ctx, owner, repo, &github.ListOptions{
-			Page:    0,
-			PerPage: 1,
-		})
+		if d.Branch != "" {
+			deps, err := c.checkBranch(ctx, owner, repo, d)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", d.Project, err)
+			}
+			outdated = append(outdated, deps...)
+			continue
+		}
+
+		available, tags, err := c.versions(ctx, owner, repo, d)
 		if err != nil {
-			return nil, fmt.Errorf("couldn't get tags for %s: %w", d.Project, err)
+			return nil, fmt.Errorf("%s: %w", d.Project, err)
 		}
-		if len(tags) == 0 {
-			return nil, fmt.Errorf("%s has no tags", d.Project)
+
+		latest, ok, err := d.Options.newestUpdate(d.Version, available, c.tagPublished(ctx, owner, repo, tags))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", d.Project, err)
+		} else if ok {
+			latest.Name = "github.com/" + sanitizedName
+			if component := d.Tags.Component(); component != "" {
+				latest.Name += "/" + component
+			}
+			latest.CurrentVersion = d.Version
+			outdated = append(outdated, latest)
 		}
+	}
+
+	return outdated, nil
+}
+
+// versions returns the available versions of d sorted from newest to
+// oldest, along with the tag each version was read from.
+func (c *Github) versions(ctx context.Context, owner, repo string, d GithubDependency) ([]Dependency, map[string]string, error) {
+	var (
+		available []Dependency
+		err       error
+	)
+	switch d.Source {
+	case GithubSourceReleases:
+		available, err = c.listReleases(ctx, owner, repo, d)
+	default:
+		available, err = c.listTags(ctx, owner, repo)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
 
-		if d.Options.IgnoreVersionPattern.Matches(tags[0].GetName()) {
+	// Filter down to the tags of the component being tracked, and
+	// remember which tag each version came from.
+	var (
+		filtered = available[:0]
+		tags     = make(map[string]string, len(available))
+	)
+	for _, r := range available {
+		version, ok := d.Tags.Version(r.LatestVersion)
+		if !ok {
 			continue
 		}
+		tags[version] = r.LatestVersion
+		r.LatestVersion = version
+		filtered = append(filtered, r)
+	}
 
-		if semver.Compare(d.Version, tags[0].GetName()) == -1 {
-			outdated = append(outdated, Dependency{
-				Name:           "github.com/" + sanitizedName,
-				CurrentVersion: d.Version,
-				LatestVersion:  tags[0].GetName(),
-			})
+	available, err = d.Options.sortReleases(filtered)
+	if err != nil {
+		return nil, nil, err
+	} else if len(available) == 0 {
+		return nil, nil, fmt.Errorf("no valid version tags found")
+	}
+	return available, tags, nil
+}
+
+// checkBranch checks a dependency pinned to a commit on d.Branch. The head
+// of the branch is reported as "github.com/owner/repo@branch" with kind
+// UpdateKindCommits if it is ahead of the pinned commit. If d.ReportTags is
+// set, the newest tag is also reported if it contains the pinned commit, or
+// points to it, so the pin can be replaced with the tag. Versions of commits
+// are short SHAs.
+func (c *Github) checkBranch(ctx context.Context, owner, repo string, d GithubDependency) ([]Dependency, error) {
+	var (
+		outdated []Dependency
+		name     = "github.com/" + owner + "/" + repo
+
+		// Page size is set to 1 since only the summary of comparisons is
+		// used.
+		opts = &github.ListOptions{PerPage: 1}
+	)
+
+	branch, _, err := c.cli.Repositories.GetBranch(ctx, owner, repo, d.Branch, false)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get branch %s: %w", d.Branch, err)
+	}
+	head := branch.GetCommit()
+
+	cmp, _, err := c.cli.Repositories.CompareCommits(ctx, owner, repo, d.Version, head.GetSHA(), opts)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't compare %s to %s: %w", d.Version, d.Branch, err)
+	}
+	if cmp.GetAheadBy() > 0 {
+		outdated = append(outdated, Dependency{
+			Name:           name + "@" + d.Branch,
+			Kind:           UpdateKindCommits,
+			CurrentVersion: shortHash(d.Version),
+			LatestVersion:  shortHash(head.GetSHA()),
+			PublishedAt:    head.GetCommit().GetCommitter().GetDate(),
+			CommitsAhead:   cmp.GetAheadBy(),
+			CompareURL:     cmp.GetHTMLURL(),
+		})
+	}
+
+	if !d.ReportTags {
+		return outdated, nil
+	}
+
+	available, tags, err := c.versions(ctx, owner, repo, d)
+	if err != nil {
+		return nil, err
+	}
+	scheme, err := d.Options.scheme()
+	if err != nil {
+		return nil, err
+	}
+
+	// The pinned commit has no version, so the update policy can't be
+	// applied; other options still filter out tags.
+	tagOptions := d.Options
+	tagOptions.UpdatePolicy = ""
+
+	// Only the newest tag is checked: older tags are unlikely to contain a
+	// commit which the newest tag doesn't.
+	var (
+		newest Dependency
+		found  bool
+
+		published = c.tagPublished(ctx, owner, repo, tags)
+		now       = time.Now()
+	)
+	for _, r := range available {
+		v, ok := scheme.Parse(r.LatestVersion)
+		if !ok || !tagOptions.allows(Version{}, r.LatestVersion, v) {
+			continue
+		}
+		old, err := tagOptions.oldEnough(&r, published, now)
+		if err != nil {
+			return nil, err
+		} else if old {
+			newest, found = r, true
+			break
 		}
 	}
+	if !found {
+		return outdated, nil
+	}
+
+	tag := tags[newest.LatestVersion]
+	cmp, _, err = c.cli.Repositories.CompareCommits(ctx, owner, repo, d.Version, tag, opts)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't compare %s to %s: %w", d.Version, tag, err)
+	}
+	// A tag of the pinned commit itself is reported too, since the tag can
+	// replace the commit.
+	switch cmp.GetStatus() {
+	case "ahead", "identical":
+		newest.Name = name
+		if component := d.Tags.Component(); component != "" {
+			newest.Name += "/" + component
+		}
+		newest.CurrentVersion = shortHash(d.Version)
+		outdated = append(outdated, newest)
+	}
 
 	return outdated, nil
 }
+
+// tagPublished returns a publishedFunc which uses the commit date of the tag
+// of each version as its publish time, since tags don't record when they
+// were pushed. tags maps versions to the tags they were read from.
+func (c *Github) tagPublished(ctx context.Context, owner, repo string, tags map[string]string) publishedFunc {
+	return func(r Dependency) (time.Time, error) {
+		commit, _, err := c.cli.Repositories.GetCommit(ctx, owner, repo, tags[r.LatestVersion], nil)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return commit.GetCommit().GetCommitter().GetDate(), nil
+	}
+}
+
+// listTags returns all tags of a repository. Only the LatestVersion field of
+// the returned dependencies is set.
+func (c *Github) listTags(ctx context.Context, owner, repo string) ([]Dependency, error) {
+	var available []Dependency
+
+	opts := &github.ListOptions{PerPage: githubPageSize}
+	for page := 0; page < githubMaxPages; page++ {
+		tags, resp, err := c.cli.Repositories.ListTags(ctx, owner, repo, opts)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't get tags: %w", err)
+		}
+		for _, t := range tags {
+			available = append(available, Dependency{LatestVersion: t.GetName()})
+		}
+
+		if resp.NextPage == 0 {
+			return available, nil
+		}
+		opts.Page = resp.NextPage
+	}
+
+	log.Printf("Only checking the first %d tags of %s/%s", githubMaxPages*githubPageSize, owner, repo)
+	return available, nil
+}
+
+// listReleases returns all published releases of a repository. Drafts are
+// always skipped, and prereleases are skipped unless d allows them.
+func (c *Github) listReleases(ctx context.Context, owner, repo string, d GithubDependency) ([]Dependency, error) {
+	var available []Dependency
+
+	opts := &github.ListOptions{PerPage: githubPageSize}
+	for page := 0; page < githubMaxPages; page++ {
+		releases, resp, err := c.cli.Repositories.ListReleases(ctx, owner, repo, opts)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't get releases: %w", err)
+		}
+		for _, r := range releases {
+			if r.GetDraft() || (r.GetPrerelease() && !d.IncludePrereleases) {
+				continue
+			}
+			available = append(available, Dependency{
+				LatestVersion: r.GetTagName(),
+				URL:           r.GetHTMLURL(),
+				PublishedAt:   r.GetPublishedAt().Time,
+				ReleaseNotes:  r.GetBody(),
+			})
+		}
+
+		if resp.NextPage == 0 {
+			return available, nil
+		}
+		opts.Page = resp.NextPage
+	}
+
+	log.Printf("Only checking the first %d releases of %s/%s", githubMaxPages*githubPageSize, owner, repo)
+	return available, nil
+}
@@ -1,9 +1,18 @@
 package tracker
 
 import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
 	"regexp"
 	"testing"
+	"time"
 
+	"github.com/google/go-github/v48/github"
 	"github.com/stretchr/testify/require"
 	"gopkg.in/yaml.v2"
 )
@@ -34,6 +43,20 @@ func TestParseGithubDependency(t *testing.T) {
 				},
 			},
 		},
+		{
+			input: `{
+				"project": "github.com/prometheus/prometheus",
+				"version": "v1.2.3",
+				"source": "releases",
+				"include_prereleases": true,
+			}`,
+			expect: GithubDependency{
+				Project:            "github.com/prometheus/prometheus",
+				Version:            "v1.2.3",
+				Source:             GithubSourceReleases,
+				IncludePrereleases: true,
+			},
+		},
 	}
 
 	for _, tc := range tt {
@@ -43,3 +66,209 @@ func TestParseGithubDependency(t *testing.T) {
 		require.Equal(t, tc.expect, actual)
 	}
 }
+
+func TestParseGithubDependency_InvalidSource(t *testing.T) {
+	input := `{
+		"project": "github.com/prometheus/prometheus",
+		"version": "v1.2.3",
+		"source": "branches",
+	}`
+
+	var actual GithubDependency
+	err := yaml.Unmarshal([]byte(input), &actual)
+	require.Error(t, err)
+}
+
+func TestGithub_CheckOutdated_Pagination(t *testing.T) {
+	pages := map[string]string{
+		"":  `[{"name": "v1.2.0"}, {"name": "nightly"}]`,
+		"2": `[{"name": "v1.10.0"}, {"name": "v1.9.0"}]`,
+	}
+	cli := newTestGithubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/repos/grafana/agent/tags", r.URL.Path)
+
+		page := r.URL.Query().Get("page")
+		if page == "" {
+			w.Header().Set("Link", `<https://api.github.com/repos/grafana/agent/tags?page=2>; rel="next"`)
+		}
+		fmt.Fprint(w, pages[page])
+	})
+
+	gh := NewGithub(t.TempDir(), []GithubDependency{{Project: "github.com/grafana/agent", Version: "v1.2.0"}}, cli)
+	deps, err := gh.CheckOutdated(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []Dependency{{
+		Name:           "github.com/grafana/agent",
+		CurrentVersion: "v1.2.0",
+		LatestVersion:  "v1.10.0",
+	}}, deps)
+}
+
+func TestGithub_CheckOutdated_TagPrefix(t *testing.T) {
+	cli := newTestGithubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"name": "v0.40.0"}, {"name": "operator/v0.31.0"}, {"name": "operator/v0.30.0"}]`)
+	})
+
+	gh := NewGithub(t.TempDir(), []GithubDependency{{
+		Project: "github.com/grafana/agent",
+		Version: "v0.30.0",
+		Tags:    TagFilter{TagPrefix: "operator/"},
+	}}, cli)
+	deps, err := gh.CheckOutdated(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []Dependency{{
+		Name:           "github.com/grafana/agent/operator",
+		CurrentVersion: "v0.30.0",
+		LatestVersion:  "v0.31.0",
+	}}, deps)
+}
+
+func TestGithub_CheckOutdated_FileRefs(t *testing.T) {
+	repo := t.TempDir()
+	files := map[string]string{
+		"Makefile":             "LOKI_VERSION ?= v2.9.0\n",
+		"cmd/loki/Dockerfile":  "FROM grafana/loki:v2.9.0\n",
+		"cmd/tools/Dockerfile": "FROM alpine:3.19\n",
+	}
+	for name, content := range files {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(repo, name)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(repo, name), []byte(content), 0644))
+	}
+
+	cli := newTestGithubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"name": "v3.0.0"}, {"name": "v2.9.0"}]`)
+	})
+
+	gh := NewGithub(repo, []GithubDependency{{
+		Project: "github.com/grafana/loki",
+		FileRefs: []FileRef{
+			{Path: "Makefile", Pattern: (*Regexp)(regexp.MustCompile(`LOKI_VERSION \?= (?P<version>\S+)`))},
+			// Globs may match several files, as long as the pattern is only
+			// found once.
+			{Path: "cmd/*/Dockerfile", Pattern: (*Regexp)(regexp.MustCompile(`grafana/loki:(?P<version>\S+)`))},
+		},
+	}}, cli)
+	deps, err := gh.CheckOutdated(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []Dependency{{
+		Name:           "github.com/grafana/loki",
+		CurrentVersion: "v2.9.0",
+		LatestVersion:  "v3.0.0",
+	}}, deps)
+
+	// The version can't be read if the pattern is found in several files.
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "cmd/tools/Dockerfile"), []byte("FROM grafana/loki:v2.9.0\n"), 0644))
+	_, err = gh.CheckOutdated(context.Background())
+	require.EqualError(t, err, `github.com/grafana/loki: file_refs: pattern "grafana/loki:(?P<version>\\S+)" matches 2 times (cmd/loki/Dockerfile:1, cmd/tools/Dockerfile:1); it must match exactly once`)
+}
+
+func TestGithub_CheckOutdated_NoValidTags(t *testing.T) {
+	cli := newTestGithubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"name": "nightly"}, {"name": "latest"}]`)
+	})
+
+	gh := NewGithub(t.TempDir(), []GithubDependency{{Project: "github.com/grafana/agent", Version: "v1.2.0"}}, cli)
+	_, err := gh.CheckOutdated(context.Background())
+	require.EqualError(t, err, "github.com/grafana/agent: no valid version tags found")
+}
+
+func TestGithub_CheckOutdated_Branch(t *testing.T) {
+	const (
+		pinned = "abcdef1"
+		head   = "0123456789abcdef0123456789abcdef01234567"
+	)
+	committed := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	cli := newTestGithubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/grafana/agent/branches/main":
+			fmt.Fprintf(w, `{"commit": {"sha": %q, "commit": {"committer": {"date": %q}}}}`, head, committed.Format(time.RFC3339))
+		case "/repos/grafana/agent/compare/" + pinned + "..." + head:
+			fmt.Fprint(w, `{"status": "ahead", "ahead_by": 4, "html_url": "https://github.com/grafana/agent/compare/abcdef1...0123456"}`)
+		case "/repos/grafana/agent/tags":
+			fmt.Fprint(w, `[{"name": "v0.41.0-rc.1"}, {"name": "v0.40.0"}, {"name": "v0.39.0"}]`)
+		case "/repos/grafana/agent/compare/" + pinned + "...v0.40.0":
+			fmt.Fprint(w, `{"status": "ahead", "ahead_by": 12}`)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	gh := NewGithub(t.TempDir(), []GithubDependency{{
+		Project:    "github.com/grafana/agent",
+		Version:    pinned,
+		Branch:     "main",
+		ReportTags: true,
+		Options:    DependencyOptions{IgnoreVersionPattern: (*Regexp)(regexp.MustCompile("-rc"))},
+	}}, cli)
+	deps, err := gh.CheckOutdated(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []Dependency{
+		{
+			Name:           "github.com/grafana/agent@main",
+			Kind:           UpdateKindCommits,
+			CurrentVersion: "abcdef1",
+			LatestVersion:  "0123456",
+			PublishedAt:    committed,
+			CommitsAhead:   4,
+			CompareURL:     "https://github.com/grafana/agent/compare/abcdef1...0123456",
+		},
+		{Name: "github.com/grafana/agent", CurrentVersion: "abcdef1", LatestVersion: "v0.40.0"},
+	}, deps)
+}
+
+func TestGithub_CheckOutdated_BranchTags(t *testing.T) {
+	const pinned = "abcdef1"
+	var (
+		now      = time.Now().UTC().Truncate(time.Second)
+		released = now.Add(-96 * time.Hour)
+	)
+
+	cli := newTestGithubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/grafana/agent/branches/main":
+			fmt.Fprintf(w, `{"commit": {"sha": %q}}`, pinned)
+		case "/repos/grafana/agent/compare/" + pinned + "..." + pinned:
+			fmt.Fprint(w, `{"status": "identical"}`)
+		case "/repos/grafana/agent/tags":
+			fmt.Fprint(w, `[{"name": "v0.41.0"}, {"name": "v0.40.0"}]`)
+		case "/repos/grafana/agent/commits/v0.41.0":
+			fmt.Fprintf(w, `{"commit": {"committer": {"date": %q}}}`, now.Format(time.RFC3339))
+		case "/repos/grafana/agent/commits/v0.40.0":
+			fmt.Fprintf(w, `{"commit": {"committer": {"date": %q}}}`, released.Format(time.RFC3339))
+		case "/repos/grafana/agent/compare/" + pinned + "...v0.40.0":
+			// The pinned commit is the tagged commit.
+			fmt.Fprint(w, `{"status": "identical"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	gh := NewGithub(t.TempDir(), []GithubDependency{{
+		Project:    "github.com/grafana/agent",
+		Version:    pinned,
+		Branch:     "main",
+		ReportTags: true,
+		Options:    DependencyOptions{MinAge: 72 * time.Hour},
+	}}, cli)
+	deps, err := gh.CheckOutdated(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []Dependency{
+		{Name: "github.com/grafana/agent", CurrentVersion: "abcdef1", LatestVersion: "v0.40.0", PublishedAt: released},
+	}, deps)
+}
+
+// newTestGithubClient returns a Github client which sends all requests to
+// handler.
+func newTestGithubClient(t *testing.T, handler http.HandlerFunc) *github.Client {
+	t.Helper()
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	cli := github.NewClient(srv.Client())
+	baseURL, err := url.Parse(srv.URL + "/")
+	require.NoError(t, err)
+	cli.BaseURL = baseURL
+	return cli
+}
@@ -0,0 +1,348 @@
+package tracker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultGitlabURL is the GitLab instance used when no base URL is
+	// configured.
+	defaultGitlabURL = "https://gitlab.com"
+
+	// gitlabPageSize is the number of items requested per page from the
+	// GitLab API.
+	gitlabPageSize = 100
+	// gitlabMaxPages caps the number of pages of tags or releases read for a
+	// single dependency.
+	gitlabMaxPages = 10
+)
+
+// Gitlab checks for outdated dependencies on GitLab projects.
+type Gitlab struct {
+	repo    string
+	check   []GitlabDependency
+	baseURL string
+	cli     *http.Client
+
+	// token authenticates requests to tokenHost, the host of baseURL.
+	// Projects on other instances are requested without it.
+	token, tokenHost string
+}
+
+// GitlabDependency is a dependency on a GitLab project.
+type GitlabDependency struct {
+	// Project is the full path of the project, such as
+	// gitlab-org/gitlab-runner.
+	Project string `yaml:"project"`
+	Version string `yaml:"version"`
+
+	// FileRefs reads Version from files in the repository instead of the
+	// config.
+	FileRefs []FileRef `yaml:"file_refs"`
+
+	// BaseURL is the URL of the GitLab instance hosting the project.
+	// Defaults to the gitlab_url of the config.
+	BaseURL string `yaml:"base_url"`
+
+	// Source is where available versions are read from. Defaults to
+	// GitlabSourceTags.
+	Source GitlabSource `yaml:"source"`
+
+	// Tags filters tags to a single component of the project. Version is
+	// then the version of that component.
+	Tags TagFilter `yaml:",inline"`
+
+	Options DependencyOptions `yaml:",inline"`
+}
+
+// GitlabSource is a source of versions for a GitlabDependency.
+type GitlabSource string
+
+// Supported GitlabSource values.
+const (
+	// GitlabSourceTags reads versions from the project tags.
+	GitlabSourceTags GitlabSource = "tags"
+	// GitlabSourceReleases reads versions from GitLab releases. Upcoming
+	// releases are skipped.
+	GitlabSourceReleases GitlabSource = "releases"
+)
+
+// UnmarshalYAML unmarshals a GitlabSource, ensuring that it is valid.
+func (s *GitlabSource) UnmarshalYAML(f func(v interface{}) error) error {
+	var str string
+	if err := f(&str); err != nil {
+		return err
+	}
+	switch v := GitlabSource(str); v {
+	case GitlabSourceTags, GitlabSourceReleases:
+		*s = v
+		return nil
+	default:
+		return fmt.Errorf("invalid source %q: expected %q or %q", str, GitlabSourceTags, GitlabSourceReleases)
+	}
+}
+
+// UnmarshalYAML will unmarshal a string or an object into a GitlabDependency.
+func (d *GitlabDependency) UnmarshalYAML(f func(interface{}) error) error {
+	var (
+		stringError error
+		objectError error
+	)
+
+	// Try as a raw string
+	var s string
+	stringError = f(&s)
+	if stringError == nil {
+		parts := strings.SplitN(s, " ", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("invalid dependency %s: expected format '[project path] [version]'", s)
+		}
+		d.Project, d.Version = parts[0], parts[1]
+		return nil
+	}
+
+	// Then a whole object
+	type gitlabDependency GitlabDependency
+	var v gitlabDependency
+	objectError = f(&v)
+	if objectError == nil {
+		*d = GitlabDependency(v)
+		return nil
+	}
+
+	return fmt.Errorf(
+		"could not parse GitLab dependency as a string (%s) or an object (%s)",
+		stringError,
+		objectError,
+	)
+}
+
+// NewGitlab creates a new Gitlab tracker. File references of dependencies
+// are relative to repo, baseURL is the default GitLab instance, and token is
+// used to authenticate requests to baseURL if it is non-empty. Requests to
+// other instances, set by the BaseURL of a dependency, are unauthenticated.
+func NewGitlab(repo string, check []GitlabDependency, baseURL, token string) *Gitlab {
+	if baseURL == "" {
+		baseURL = defaultGitlabURL
+	}
+	c := &Gitlab{repo: repo, check: check, baseURL: baseURL, cli: http.DefaultClient}
+	if u, err := url.Parse(baseURL); err == nil && token != "" {
+		c.token, c.tokenHost = token, u.Host
+	}
+	return c
+}
+
+// CheckOutdated will return the list of GitLab dependencies that can be
+// updated.
+func (c *Gitlab) CheckOutdated(ctx context.Context) ([]Dependency, error) {
+	var outdated []Dependency
+
+	for _, d := range c.check {
+		version, err := currentVersion(c.repo, d.Version, d.FileRefs)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", d.Project, err)
+		}
+		d.Version = version
+
+		baseURL := d.BaseURL
+		if baseURL == "" {
+			baseURL = c.baseURL
+		}
+		u, err := url.Parse(baseURL)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid base URL: %w", d.Project, err)
+		}
+		// The host may be included in the project for readability, e.g.,
+		// gitlab.com/gitlab-org/gitlab-runner.
+		project := strings.TrimPrefix(d.Project, u.Host+"/")
+
+		var available []Dependency
+		switch d.Source {
+		case GitlabSourceReleases:
+			available, err = c.listReleases(ctx, baseURL, project)
+		default:
+			available, err = c.listTags(ctx, baseURL, project)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", d.Project, err)
+		}
+
+		filtered := available[:0]
+		tagNames := make(map[string]string, len(available))
+		for _, r := range available {
+			if version, ok := d.Tags.Version(r.LatestVersion); ok {
+				tagNames[version] = r.LatestVersion
+				r.LatestVersion = version
+				filtered = append(filtered, r)
+			}
+		}
+		available, err = d.Options.sortReleases(filtered)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", d.Project, err)
+		} else if len(available) == 0 {
+			return nil, fmt.Errorf("%s: no valid version tags found", d.Project)
+		}
+
+		// Tags and releases both include when they were published, but
+		// tags of some GitLab versions omit their commit, so its date is
+		// requested separately.
+		latest, ok, err := d.Options.newestUpdate(d.Version, available, func(r Dependency) (time.Time, error) {
+			return c.commitDate(ctx, baseURL, project, tagNames[r.LatestVersion])
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", d.Project, err)
+		} else if ok {
+			latest.Name = u.Host + "/" + project
+			if component := d.Tags.Component(); component != "" {
+				latest.Name += "/" + component
+			}
+			latest.CurrentVersion = d.Version
+			outdated = append(outdated, latest)
+		}
+	}
+
+	return outdated, nil
+}
+
+// listTags returns all tags of a project. PublishedAt is set to the time
+// of the tagged commit.
+func (c *Gitlab) listTags(ctx context.Context, baseURL, project string) ([]Dependency, error) {
+	var available []Dependency
+
+	err := c.list(ctx, baseURL, project, "repository/tags", func(dec *json.Decoder) error {
+		var tags []struct {
+			Name   string `json:"name"`
+			Commit struct {
+				CommittedDate time.Time `json:"committed_date"`
+			} `json:"commit"`
+		}
+		if err := dec.Decode(&tags); err != nil {
+			return err
+		}
+		for _, t := range tags {
+			available = append(available, Dependency{LatestVersion: t.Name, PublishedAt: t.Commit.CommittedDate})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get tags: %w", err)
+	}
+	return available, nil
+}
+
+// listReleases returns all published releases of a project. Upcoming
+// releases are skipped.
+func (c *Gitlab) listReleases(ctx context.Context, baseURL, project string) ([]Dependency, error) {
+	var available []Dependency
+
+	err := c.list(ctx, baseURL, project, "releases", func(dec *json.Decoder) error {
+		var releases []struct {
+			TagName         string    `json:"tag_name"`
+			Description     string    `json:"description"`
+			ReleasedAt      time.Time `json:"released_at"`
+			UpcomingRelease bool      `json:"upcoming_release"`
+			Links           struct {
+				Self string `json:"self"`
+			} `json:"_links"`
+		}
+		if err := dec.Decode(&releases); err != nil {
+			return err
+		}
+		for _, r := range releases {
+			if r.UpcomingRelease {
+				continue
+			}
+			available = append(available, Dependency{
+				LatestVersion: r.TagName,
+				URL:           r.Links.Self,
+				PublishedAt:   r.ReleasedAt,
+				ReleaseNotes:  r.Description,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get releases: %w", err)
+	}
+	return available, nil
+}
+
+// commitDate returns the time the commit of a tag was committed.
+func (c *Gitlab) commitDate(ctx context.Context, baseURL, project, tag string) (time.Time, error) {
+	endpoint := projectEndpoint(baseURL, project, "repository/commits/"+url.PathEscape(tag))
+
+	resp, err := c.get(ctx, endpoint)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer resp.Body.Close()
+
+	var commit struct {
+		CommittedDate time.Time `json:"committed_date"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&commit); err != nil {
+		return time.Time{}, fmt.Errorf("failed to decode response from %s: %w", endpoint, err)
+	}
+	return commit.CommittedDate, nil
+}
+
+// list requests every page of a project API resource, calling decode with
+// the body of each page.
+func (c *Gitlab) list(ctx context.Context, baseURL, project, resource string, decode func(*json.Decoder) error) error {
+	endpoint := projectEndpoint(baseURL, project, resource)
+
+	page := "1"
+	for i := 0; i < gitlabMaxPages; i++ {
+		resp, err := c.get(ctx, endpoint+"?per_page="+strconv.Itoa(gitlabPageSize)+"&page="+page)
+		if err != nil {
+			return err
+		}
+		err = decode(json.NewDecoder(resp.Body))
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("failed to decode response from %s: %w", endpoint, err)
+		}
+
+		if page = resp.Header.Get("X-Next-Page"); page == "" {
+			return nil
+		}
+	}
+
+	log.Printf("Only checking the first %d items of %s", gitlabMaxPages*gitlabPageSize, endpoint)
+	return nil
+}
+
+// get requests endpoint, returning an error unless the response is
+// successful.
+func (c *Gitlab) get(ctx context.Context, endpoint string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.token != "" && req.URL.Host == c.tokenHost {
+		req.Header.Set("PRIVATE-TOKEN", c.token)
+	}
+
+	resp, err := c.cli.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s: unexpected status %s", endpoint, resp.Status)
+	}
+	return resp, nil
+}
+
+// projectEndpoint returns the URL of a project API resource.
+func projectEndpoint(baseURL, project, resource string) string {
+	return fmt.Sprintf("%s/api/v4/projects/%s/%s", strings.TrimSuffix(baseURL, "/"), url.PathEscape(project), resource)
+}
@@ -0,0 +1,169 @@
+package tracker
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+func TestParseGitlabDependency(t *testing.T) {
+	tt := []struct {
+		input  string
+		expect GitlabDependency
+	}{
+		{
+			input:  `"gitlab-org/gitlab-runner v16.0.0"`,
+			expect: GitlabDependency{Project: "gitlab-org/gitlab-runner", Version: "v16.0.0"},
+		},
+		{
+			input: `{
+				"project": "platform/deploy-tools",
+				"version": "v1.4.0",
+				"base_url": "https://gitlab.example.com",
+				"source": "releases",
+			}`,
+			expect: GitlabDependency{
+				Project: "platform/deploy-tools",
+				Version: "v1.4.0",
+				BaseURL: "https://gitlab.example.com",
+				Source:  GitlabSourceReleases,
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		var actual GitlabDependency
+		err := yaml.Unmarshal([]byte(tc.input), &actual)
+		require.NoError(t, err)
+		require.Equal(t, tc.expect, actual)
+	}
+}
+
+func TestParseGitlabDependency_InvalidSource(t *testing.T) {
+	var actual GitlabDependency
+	err := yaml.Unmarshal([]byte(`{"project": "gitlab-org/gitlab-runner", "version": "v16.0.0", "source": "branches"}`), &actual)
+	require.Error(t, err)
+}
+
+func TestGitlab_CheckOutdated(t *testing.T) {
+	released := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v4/projects/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PRIVATE-TOKEN") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		// The project path is escaped into a single path segment.
+		switch path := r.URL.EscapedPath(); {
+		case path == "/api/v4/projects/group%2Fproject/repository/tags" && r.URL.Query().Get("page") == "1":
+			w.Header().Set("X-Next-Page", "2")
+			fmt.Fprint(w, `[{"name": "v1.0.0"}, {"name": "nightly"}]`)
+		case path == "/api/v4/projects/group%2Fproject/repository/tags" && r.URL.Query().Get("page") == "2":
+			fmt.Fprintf(w, `[{"name": "v1.2.0", "commit": {"committed_date": %q}}]`, released.Format(time.RFC3339))
+		case path == "/api/v4/projects/group%2Fproject/releases":
+			fmt.Fprintf(w, `[
+				{"tag_name": "v2.0.0", "upcoming_release": true},
+				{"tag_name": "v1.1.0", "description": "Bug fixes", "released_at": %q, "_links": {"self": "https://gitlab.example.com/group/project/-/releases/v1.1.0"}}
+			]`, released.Format(time.RFC3339))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	g := NewGitlab(t.TempDir(), []GitlabDependency{
+		{Project: "group/project", Version: "v1.0.0"},
+		{Project: host + "/group/project", Version: "v1.0.0", Source: GitlabSourceReleases},
+	}, srv.URL, "secret")
+	deps, err := g.CheckOutdated(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []Dependency{
+		{Name: host + "/group/project", CurrentVersion: "v1.0.0", LatestVersion: "v1.2.0", PublishedAt: released},
+		{
+			Name:           host + "/group/project",
+			CurrentVersion: "v1.0.0",
+			LatestVersion:  "v1.1.0",
+			URL:            "https://gitlab.example.com/group/project/-/releases/v1.1.0",
+			PublishedAt:    released,
+			ReleaseNotes:   "Bug fixes",
+		},
+	}, deps)
+
+	// Requests without the token are rejected.
+	g = NewGitlab(t.TempDir(), []GitlabDependency{{Project: "group/project", Version: "v1.0.0", BaseURL: srv.URL}}, "", "")
+	_, err = g.CheckOutdated(context.Background())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "401")
+}
+
+func TestGitlab_CheckOutdated_MinAge(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Tags without their commit have its date requested separately.
+		switch r.URL.EscapedPath() {
+		case "/api/v4/projects/group%2Fproject/repository/tags":
+			fmt.Fprintf(w, `[
+				{"name": "v1.2.0", "commit": {"committed_date": %q}},
+				{"name": "v1.1.0"},
+				{"name": "v1.0.0"}
+			]`, now.Format(time.RFC3339))
+		case "/api/v4/projects/group%2Fproject/repository/commits/v1.1.0":
+			fmt.Fprintf(w, `{"committed_date": %q}`, now.Add(-96*time.Hour).Format(time.RFC3339))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	g := NewGitlab(t.TempDir(), []GitlabDependency{
+		{Project: "group/project", Version: "v1.0.0", Options: DependencyOptions{MinAge: 72 * time.Hour}},
+	}, srv.URL, "")
+	deps, err := g.CheckOutdated(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []Dependency{{
+		Name:           strings.TrimPrefix(srv.URL, "http://") + "/group/project",
+		CurrentVersion: "v1.0.0",
+		LatestVersion:  "v1.1.0",
+		PublishedAt:    now.Add(-96 * time.Hour),
+	}}, deps)
+}
+
+func TestGitlab_CheckOutdated_ForeignHost(t *testing.T) {
+	// The token of the configured instance isn't sent to other instances.
+	var (
+		mut    sync.Mutex
+		tokens []string
+	)
+	foreign := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mut.Lock()
+		tokens = append(tokens, r.Header.Get("PRIVATE-TOKEN"))
+		mut.Unlock()
+		fmt.Fprint(w, `[{"name": "v1.1.0", "commit": {"committed_date": "2024-05-01T00:00:00Z"}}]`)
+	}))
+	t.Cleanup(foreign.Close)
+
+	g := NewGitlab(t.TempDir(), []GitlabDependency{
+		{Project: "group/project", Version: "v1.0.0", BaseURL: foreign.URL},
+	}, "https://gitlab.example.com", "secret")
+	deps, err := g.CheckOutdated(context.Background())
+	require.NoError(t, err)
+	require.Len(t, deps, 1)
+
+	mut.Lock()
+	defer mut.Unlock()
+	require.Equal(t, []string{""}, tokens)
+}
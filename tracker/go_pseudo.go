@@ -0,0 +1,119 @@
+package tracker
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/google/go-github/v48/github"
+	"golang.org/x/mod/module"
+)
+
+// checkPseudoVersion refines the update found for a module pinned to a
+// pseudo-version. Pseudo-versions identify a commit, but are only compared
+// to tagged releases by version, so a newer release may not actually contain
+// the commit. For modules hosted on Github, the commit is compared against
+// the release and the default branch:
+//
+//   - If latest is set to a release which contains the commit, latest is
+//     returned with CompareURL set.
+//   - Otherwise, if the default branch is ahead of the commit, an update of
+//     kind UpdateKindCommits to the head of the default branch is returned.
+//
+// Modules which aren't hosted on Github are returned unchanged. So are modules
+// which can't be compared, such as when the repository is private or the
+// Github API is rate limited, after logging why. Only context errors are
+// returned.
+func (c *GoModules) checkPseudoVersion(ctx context.Context, mod *moduleInfo, latest Dependency, ok bool) (Dependency, bool, error) {
+	owner, repo, dir, isGithub := githubModuleRepo(mod.Path)
+	if !isGithub || c.cli == nil {
+		return latest, ok, nil
+	}
+
+	refined, refinedOK, err := c.comparePseudoVersion(ctx, mod, owner, repo, dir, latest, ok)
+	if ctx.Err() != nil {
+		return Dependency{}, false, ctx.Err()
+	} else if err != nil {
+		log.Printf("Couldn't compare %s %s to its releases: %s", mod.Path, mod.Version, err)
+		return latest, ok, nil
+	}
+	return refined, refinedOK, nil
+}
+
+// comparePseudoVersion implements checkPseudoVersion for the module mod
+// hosted in the Github repository owner/repo, with tags prefixed by dir.
+func (c *GoModules) comparePseudoVersion(ctx context.Context, mod *moduleInfo, owner, repo, dir string, latest Dependency, ok bool) (Dependency, bool, error) {
+	rev, err := module.PseudoVersionRev(mod.Version)
+	if err != nil {
+		return Dependency{}, false, err
+	}
+
+	// Page size is set to 1 since only the summary of comparisons is used.
+	opts := &github.ListOptions{PerPage: 1}
+
+	// Modules without releases have a pseudo-version as their latest
+	// version, which isn't a git ref, so they're compared to the default
+	// branch instead.
+	if ok && !module.IsPseudoVersion(latest.LatestVersion) {
+		cmp, _, err := c.cli.Repositories.CompareCommits(ctx, owner, repo, rev, dir+latest.LatestVersion, opts)
+		if err != nil {
+			return Dependency{}, false, fmt.Errorf("couldn't compare %s to %s: %w", rev, latest.LatestVersion, err)
+		}
+		switch cmp.GetStatus() {
+		case "ahead", "identical":
+			latest.CompareURL = cmp.GetHTMLURL()
+			return latest, true, nil
+		}
+	}
+
+	// No release contains the commit; compare it to the default branch
+	// instead.
+	r, _, err := c.cli.Repositories.Get(ctx, owner, repo)
+	if err != nil {
+		return Dependency{}, false, fmt.Errorf("couldn't get repository: %w", err)
+	}
+	branch, _, err := c.cli.Repositories.GetBranch(ctx, owner, repo, r.GetDefaultBranch(), false)
+	if err != nil {
+		return Dependency{}, false, fmt.Errorf("couldn't get default branch: %w", err)
+	}
+	head := branch.GetCommit()
+
+	cmp, _, err := c.cli.Repositories.CompareCommits(ctx, owner, repo, rev, head.GetSHA(), opts)
+	if err != nil {
+		return Dependency{}, false, fmt.Errorf("couldn't compare %s to %s: %w", rev, r.GetDefaultBranch(), err)
+	}
+	if cmp.GetAheadBy() == 0 {
+		return Dependency{}, false, nil
+	}
+
+	// Report the pseudo-version of the head commit so it can be used in
+	// go.mod. The module proxy resolves commit hashes to pseudo-versions.
+	version := shortHash(head.GetSHA())
+	if info, err := c.proxy.Info(ctx, mod.Path, head.GetSHA()); err == nil {
+		version = info.Version
+	}
+
+	return Dependency{
+		Kind:          UpdateKindCommits,
+		LatestVersion: version,
+		PublishedAt:   head.GetCommit().GetCommitter().GetDate(),
+		CommitsAhead:  cmp.GetAheadBy(),
+		CompareURL:    cmp.GetHTMLURL(),
+	}, true, nil
+}
+
+// githubModuleRepo returns the Github repository hosting the module path.
+// dir is the tag prefix of the module's directory within the repository,
+// such as "operator/", or empty for the root of the repository.
+func githubModuleRepo(path string) (owner, repo, dir string, ok bool) {
+	prefix, _, _ := module.SplitPathVersion(path)
+	parts := strings.SplitN(prefix, "/", 4)
+	if len(parts) < 3 || parts[0] != "github.com" {
+		return "", "", "", false
+	}
+	if len(parts) == 4 {
+		dir = parts[3] + "/"
+	}
+	return parts[1], parts[2], dir, true
+}
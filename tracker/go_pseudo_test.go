@@ -0,0 +1,184 @@
+package tracker
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoModules_PseudoVersion(t *testing.T) {
+	const (
+		current = "v0.1.1-0.20230101000000-abcdef123456"
+		head    = "0123456789abcdef0123456789abcdef01234567"
+	)
+
+	proxy := t.TempDir()
+	writeProxyModule(t, proxy, "github.com/grafana/agent/operator", "v0.2.0")
+	info := `{"Version":"v0.2.1-0.20230301000000-0123456789ab","Time":"2023-03-01T00:00:00Z"}`
+	err := os.WriteFile(filepath.Join(proxy, "github.com", "grafana", "agent", "operator", "@v", head+".info"), []byte(info), 0644)
+	require.NoError(t, err)
+	t.Setenv("GOPROXY", "file://"+filepath.ToSlash(proxy))
+
+	tt := []struct {
+		name string
+		// releaseStatus is the status of comparing the commit to the v0.2.0
+		// release.
+		releaseStatus string
+		// branchAhead is how many commits the default branch is ahead of the
+		// commit.
+		branchAhead int
+		// compareError fails comparing commits, like when the API is rate
+		// limited.
+		compareError bool
+
+		expect Dependency
+		ok     bool
+	}{
+		{
+			name:          "release contains commit",
+			releaseStatus: "ahead",
+			expect: Dependency{
+				LatestVersion: "v0.2.0",
+				CompareURL:    "https://github.com/grafana/agent/compare/abcdef123456...operator/v0.2.0",
+			},
+			ok: true,
+		},
+		{
+			name:          "newer commits",
+			releaseStatus: "diverged",
+			branchAhead:   3,
+			expect: Dependency{
+				Kind:          UpdateKindCommits,
+				LatestVersion: "v0.2.1-0.20230301000000-0123456789ab",
+				CommitsAhead:  3,
+				CompareURL:    "https://github.com/grafana/agent/compare/abcdef123456..." + head,
+			},
+			ok: true,
+		},
+		{
+			name:          "up to date",
+			releaseStatus: "diverged",
+		},
+		{
+			name:         "compare fails",
+			compareError: true,
+			expect:       Dependency{LatestVersion: "v0.2.0"},
+			ok:           true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			cli := newTestGithubClient(t, func(w http.ResponseWriter, r *http.Request) {
+				if tc.compareError && strings.Contains(r.URL.Path, "/compare/") {
+					w.WriteHeader(http.StatusForbidden)
+					fmt.Fprint(w, `{"message": "API rate limit exceeded"}`)
+					return
+				}
+				switch r.URL.Path {
+				case "/repos/grafana/agent":
+					fmt.Fprint(w, `{"default_branch": "main"}`)
+				case "/repos/grafana/agent/branches/main":
+					fmt.Fprintf(w, `{"commit": {"sha": %q}}`, head)
+				case "/repos/grafana/agent/compare/abcdef123456...operator/v0.2.0":
+					fmt.Fprintf(w, `{"status": %q, "html_url": "https://github.com/grafana/agent/compare/abcdef123456...operator/v0.2.0"}`, tc.releaseStatus)
+				case "/repos/grafana/agent/compare/abcdef123456..." + head:
+					fmt.Fprintf(w, `{"status": "ahead", "ahead_by": %d, "html_url": "https://github.com/grafana/agent/compare/abcdef123456...%s"}`, tc.branchAhead, head)
+				default:
+					http.NotFound(w, r)
+				}
+			})
+
+			gm := NewGoModules(t.TempDir(), GoModulesConfig{}, cli)
+			mod := &moduleInfo{Path: "github.com/grafana/agent/operator", Version: current}
+
+			actual, ok, err := gm.checkPseudoVersion(context.Background(), mod, Dependency{LatestVersion: "v0.2.0"}, true)
+			require.NoError(t, err)
+			require.Equal(t, tc.ok, ok)
+			require.Equal(t, tc.expect, actual)
+		})
+	}
+}
+
+func TestGithubModuleRepo(t *testing.T) {
+	tt := []struct {
+		path                string
+		owner, repo, prefix string
+		ok                  bool
+	}{
+		{path: "github.com/grafana/agent", owner: "grafana", repo: "agent", ok: true},
+		{path: "github.com/grafana/agent/v2", owner: "grafana", repo: "agent", ok: true},
+		{path: "github.com/grafana/agent/operator/v2", owner: "grafana", repo: "agent", prefix: "operator/", ok: true},
+		{path: "golang.org/x/mod"},
+	}
+
+	for _, tc := range tt {
+		owner, repo, prefix, ok := githubModuleRepo(tc.path)
+		require.Equal(t, tc.owner, owner, tc.path)
+		require.Equal(t, tc.repo, repo, tc.path)
+		require.Equal(t, tc.prefix, prefix, tc.path)
+		require.Equal(t, tc.ok, ok, tc.path)
+	}
+}
+
+func TestGoModules_CheckOutdated_Untagged(t *testing.T) {
+	const (
+		current = "v0.0.0-20230101000000-abcdef123456"
+		latest  = "v0.0.0-20240101000000-0123456789ab"
+		head    = "0123456789ab0123456789ab0123456789ab0123"
+	)
+
+	// The module has no releases, so its latest version is the
+	// pseudo-version of the head of its default branch.
+	proxy := t.TempDir()
+	writeProxyModule(t, proxy, "github.com/grafana/tool")
+	dir := filepath.Join(proxy, "github.com", "grafana", "tool", "@v")
+	info := fmt.Sprintf(`{"Version":%q,"Time":"2024-01-01T00:00:00Z"}`, latest)
+	for name, content := range map[string]string{
+		"../@latest":      info,
+		latest + ".info":  info,
+		latest + ".mod":   "module github.com/grafana/tool\n",
+		current + ".info": fmt.Sprintf(`{"Version":%q,"Time":"2023-01-01T00:00:00Z"}`, current),
+		current + ".mod":  "module github.com/grafana/tool\n",
+		head + ".info":    info,
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	t.Setenv("GOPROXY", "file://"+filepath.ToSlash(proxy))
+
+	repo := t.TempDir()
+	err := os.WriteFile(filepath.Join(repo, "go.mod"), []byte("module example.com/test\n\ngo 1.21\n\nrequire github.com/grafana/tool "+current+"\n"), 0644)
+	require.NoError(t, err)
+
+	cli := newTestGithubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/grafana/tool":
+			fmt.Fprint(w, `{"default_branch": "main"}`)
+		case "/repos/grafana/tool/branches/main":
+			fmt.Fprintf(w, `{"commit": {"sha": %q}}`, head)
+		case "/repos/grafana/tool/compare/abcdef123456..." + head:
+			fmt.Fprintf(w, `{"status": "ahead", "ahead_by": 5, "html_url": "https://github.com/grafana/tool/compare/abcdef123456...%s"}`, head)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	gm := NewGoModules(repo, GoModulesConfig{Modules: []GoModule{{Name: "github.com/grafana/tool"}}}, cli)
+	deps, err := gm.CheckOutdated(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []Dependency{{
+		Name:           "github.com/grafana/tool",
+		Kind:           UpdateKindCommits,
+		CurrentVersion: current,
+		LatestVersion:  latest,
+		CommitsAhead:   5,
+		CompareURL:     "https://github.com/grafana/tool/compare/abcdef123456..." + head,
+		UsedBy:         []string{"."},
+	}}, deps)
+}
@@ -0,0 +1,318 @@
+package tracker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/semver"
+)
+
+const (
+	// defaultGoReleaseIndex lists every Go release.
+	defaultGoReleaseIndex = "https://go.dev/dl/?mode=json&include=all"
+	// defaultGoSupportedReleases is the number of major Go releases which are
+	// supported at once.
+	defaultGoSupportedReleases = 2
+)
+
+// GoToolchain checks for outdated versions of Go used to build the
+// repository.
+type GoToolchain struct {
+	repo string
+	cfg  GoToolchainConfig
+	cli  *http.Client
+}
+
+// GoToolchainConfig configures where Go versions are read from.
+type GoToolchainConfig struct {
+	// GoMod are go.mod files, relative to the repository, whose go and
+	// toolchain directives are checked.
+	GoMod []string `yaml:"go_mod"`
+
+	// Dockerfiles are Dockerfiles, relative to the repository, whose
+	// "FROM golang:" images are checked.
+	Dockerfiles []string `yaml:"dockerfiles"`
+
+	// Index is the URL of the list of Go releases, in the format of
+	// https://go.dev/dl/?mode=json. Values without a scheme are read as a file
+	// relative to the repository. Defaults to defaultGoReleaseIndex.
+	Index string `yaml:"index"`
+
+	// SupportedReleases is the number of newest major Go releases which are
+	// supported, such as 1.22 and 1.21. Versions older than the supported
+	// releases are reported as unsupported. Defaults to 2.
+	SupportedReleases int `yaml:"supported_releases"`
+
+	Options DependencyOptions `yaml:",inline"`
+}
+
+// Enabled returns true if any Go versions should be checked.
+func (c *GoToolchainConfig) Enabled() bool {
+	return len(c.GoMod) > 0 || len(c.Dockerfiles) > 0
+}
+
+// NewGoToolchain creates a new GoToolchain tracker. Files in cfg are relative
+// to repo.
+func NewGoToolchain(repo string, cfg GoToolchainConfig) *GoToolchain {
+	return &GoToolchain{repo: repo, cfg: cfg, cli: http.DefaultClient}
+}
+
+// CheckOutdated will return the Go versions used by the repository which can
+// be updated or are no longer supported. Each is reported once, named "go",
+// with UsedBy listing where the version is declared and CurrentVersion set to
+// the oldest of them.
+//
+// Versions without a patch release, such as 1.22 or golang:1.22, follow the
+// newest patch release, so they're only updated to newer major releases.
+func (c *GoToolchain) CheckOutdated(ctx context.Context) ([]Dependency, error) {
+	used, err := c.usages()
+	if err != nil {
+		return nil, err
+	}
+	if len(used) == 0 {
+		return nil, nil
+	}
+
+	releases, err := c.releases(ctx)
+	if err != nil {
+		return nil, err
+	}
+	available, err := c.cfg.Options.sortReleases(releases)
+	if err != nil {
+		return nil, err
+	} else if len(available) == 0 {
+		return nil, fmt.Errorf("no Go releases found in %s", c.index())
+	}
+
+	supported := supportedGoReleases(available, c.cfg.SupportedReleases)
+	reason := fmt.Sprintf("Only the newest %d major releases of Go are supported: %s.", len(supported), strings.Join(supported, ", "))
+
+	var (
+		outdated []Dependency
+
+		// index maps a kind and latest version to its position in outdated,
+		// and sources to the usages of each.
+		index   = make(map[[2]string]int)
+		sources [][]usage
+	)
+	add := func(dep Dependency, u usage) {
+		key := [2]string{string(dep.Kind), dep.LatestVersion}
+		i, ok := index[key]
+		if !ok {
+			dep.Name = "go"
+			i = len(outdated)
+			index[key] = i
+			outdated = append(outdated, dep)
+			sources = append(sources, nil)
+		}
+		sources[i] = append(sources[i], u)
+		if semver.Compare("v"+dep.CurrentVersion, "v"+outdated[i].CurrentVersion) < 0 {
+			outdated[i].CurrentVersion = dep.CurrentVersion
+		}
+	}
+
+	for _, u := range used {
+		if !semver.IsValid("v" + u.Version) {
+			log.Printf("Ignoring Go version %q in %s: not a valid version", u.Version, u.Source)
+			continue
+		}
+
+		if semver.Compare("v"+goMajor(u.Version), "v"+supported[len(supported)-1]) < 0 {
+			add(Dependency{
+				Kind:           UpdateKindUnsupported,
+				CurrentVersion: u.Version,
+				LatestVersion:  available[0].LatestVersion,
+				Reason:         reason,
+			}, u)
+		}
+
+		candidates := available
+		if goFloating(u.Version) {
+			candidates = newerGoReleases(available, u.Version)
+		}
+		latest, ok, err := c.cfg.Options.newestUpdate(u.Version, candidates, nil)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", u.Source, err)
+		} else if ok {
+			add(Dependency{
+				CurrentVersion: u.Version,
+				LatestVersion:  latest.LatestVersion,
+			}, u)
+		}
+	}
+
+	for i := range outdated {
+		outdated[i].setUsedBy(sources[i])
+	}
+	return outdated, nil
+}
+
+// index returns the location of the release index.
+func (c *GoToolchain) index() string {
+	if c.cfg.Index == "" {
+		return defaultGoReleaseIndex
+	}
+	return c.cfg.Index
+}
+
+// releases returns the stable Go releases from the release index, without
+// the "go" prefix of their versions.
+func (c *GoToolchain) releases(ctx context.Context) ([]Dependency, error) {
+	var (
+		index = c.index()
+		b     []byte
+		err   error
+	)
+	if strings.Contains(index, "://") {
+		b, err = c.download(ctx, index)
+	} else {
+		b, err = os.ReadFile(filepath.Join(c.repo, index))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Go release index: %w", err)
+	}
+
+	var files []struct {
+		Version string `json:"version"`
+		Stable  bool   `json:"stable"`
+	}
+	if err := json.Unmarshal(b, &files); err != nil {
+		return nil, fmt.Errorf("failed to parse Go release index %s: %w", index, err)
+	}
+
+	var releases []Dependency
+	for _, f := range files {
+		if f.Stable {
+			releases = append(releases, Dependency{LatestVersion: strings.TrimPrefix(f.Version, "go")})
+		}
+	}
+	return releases, nil
+}
+
+func (c *GoToolchain) download(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.cli.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: unexpected status %s", url, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// usages returns the Go versions declared by the configured files.
+func (c *GoToolchain) usages() ([]usage, error) {
+	var used []usage
+
+	for _, name := range c.cfg.GoMod {
+		path := filepath.Join(c.repo, name)
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		f, err := modfile.Parse(path, b, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", name, err)
+		}
+
+		if f.Go != nil {
+			used = append(used, usage{Source: name + " (go)", Version: f.Go.Version})
+		}
+		if f.Toolchain != nil {
+			used = append(used, usage{Source: name + " (toolchain)", Version: strings.TrimPrefix(f.Toolchain.Name, "go")})
+		}
+	}
+
+	for _, name := range c.cfg.Dockerfiles {
+		b, err := os.ReadFile(filepath.Join(c.repo, name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		for _, version := range golangImageVersions(b) {
+			used = append(used, usage{Source: name, Version: version})
+		}
+	}
+
+	return used, nil
+}
+
+// golangImageVersions returns the Go versions of the golang images used in
+// FROM instructions of a Dockerfile, such as 1.22 for golang:1.22-alpine.
+// Images with tags that don't start with a version, such as golang:latest,
+// are skipped.
+func golangImageVersions(dockerfile []byte) []string {
+	var versions []string
+
+	for _, u := range dockerfileImages(dockerfile) {
+		image, tag := splitImageTag(u.Ref)
+		image = strings.TrimPrefix(image, "docker.io/")
+		image = strings.TrimPrefix(image, "library/")
+		if image != "golang" {
+			continue
+		}
+		version, _, _ := strings.Cut(tag, "-")
+		if semver.IsValid("v" + version) {
+			versions = append(versions, version)
+		}
+	}
+	return versions
+}
+
+// supportedGoReleases returns the n newest major releases of Go, such as
+// "1.22", from available releases sorted from newest to oldest.
+func supportedGoReleases(available []Dependency, n int) []string {
+	if n <= 0 {
+		n = defaultGoSupportedReleases
+	}
+
+	var majors []string
+	for _, r := range available {
+		major := goMajor(r.LatestVersion)
+		if len(majors) == 0 || majors[len(majors)-1] != major {
+			majors = append(majors, major)
+		}
+		if len(majors) == n {
+			break
+		}
+	}
+	return majors
+}
+
+// goFloating returns true if a Go version doesn't include a patch release,
+// such as 1.22, so it refers to the newest release of that major release.
+func goFloating(version string) bool {
+	return strings.Count(version, ".") < 2
+}
+
+// newerGoReleases returns the releases from available which belong to a
+// newer major release than version.
+func newerGoReleases(available []Dependency, version string) []Dependency {
+	var newer []Dependency
+	for _, r := range available {
+		if semver.Compare("v"+goMajor(r.LatestVersion), "v"+goMajor(version)) > 0 {
+			newer = append(newer, r)
+		}
+	}
+	return newer
+}
+
+// goMajor returns the major release of a Go version, such as "1.22" for
+// 1.22.1. Go calls these major releases, although they are minor versions in
+// terms of semantic versioning.
+func goMajor(version string) string {
+	return strings.TrimPrefix(semver.MajorMinor("v"+version), "v")
+}
@@ -0,0 +1,103 @@
+package tracker
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoToolchain_CheckOutdated(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/test\n\ngo 1.21.0\n\ntoolchain go1.22.1\n",
+		"Dockerfile": `FROM --platform=$BUILDPLATFORM golang:1.20-alpine AS build
+RUN go build .
+
+FROM golang:latest
+FROM alpine:3.16
+`,
+		"index.json": `[
+			{"version": "go1.23.2", "stable": true},
+			{"version": "go1.23rc2", "stable": false},
+			{"version": "go1.23.0", "stable": true},
+			{"version": "go1.22.8", "stable": true},
+			{"version": "go1.22.1", "stable": true},
+			{"version": "go1.21.0", "stable": true},
+			{"version": "go1.20", "stable": true}
+		]`,
+	}
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+
+	gt := NewGoToolchain(dir, GoToolchainConfig{
+		GoMod:       []string{"go.mod"},
+		Dockerfiles: []string{"Dockerfile"},
+		Index:       "index.json",
+	})
+	deps, err := gt.CheckOutdated(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []Dependency{
+		{
+			Name:           "go",
+			Kind:           UpdateKindUnsupported,
+			CurrentVersion: "1.20",
+			LatestVersion:  "1.23.2",
+			Reason:         "Only the newest 2 major releases of Go are supported: 1.23, 1.22.",
+			UsedBy:         []string{"go.mod (go) at 1.21.0", "Dockerfile at 1.20"},
+		},
+		{
+			Name:           "go",
+			CurrentVersion: "1.20",
+			LatestVersion:  "1.23.2",
+			UsedBy:         []string{"go.mod (go) at 1.21.0", "go.mod (toolchain) at 1.22.1", "Dockerfile at 1.20"},
+		},
+	}, deps)
+
+	// Update policies apply to each declared version.
+	gt.cfg.Options.UpdatePolicy = UpdatePolicyPatch
+	deps, err = gt.CheckOutdated(context.Background())
+	require.NoError(t, err)
+	require.Len(t, deps, 2)
+	require.Equal(t, "1.22.8", deps[1].LatestVersion)
+	require.Equal(t, []string{"go.mod (toolchain)"}, deps[1].UsedBy)
+}
+
+func TestGoToolchain_CheckOutdated_Floating(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":     "module example.com/test\n\ngo 1.22\n",
+		"Dockerfile": "FROM golang:1.22-alpine\n",
+		"index.json": `[
+			{"version": "go1.23.2", "stable": true},
+			{"version": "go1.22.8", "stable": true},
+			{"version": "go1.22.0", "stable": true}
+		]`,
+	}
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+
+	gt := NewGoToolchain(dir, GoToolchainConfig{
+		GoMod:       []string{"go.mod"},
+		Dockerfiles: []string{"Dockerfile"},
+		Index:       "index.json",
+	})
+	deps, err := gt.CheckOutdated(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []Dependency{{
+		Name:           "go",
+		CurrentVersion: "1.22",
+		LatestVersion:  "1.23.2",
+		UsedBy:         []string{"go.mod (go)", "Dockerfile"},
+	}}, deps)
+
+	// Versions without a patch release already use the newest one.
+	gt.cfg.Options.UpdatePolicy = UpdatePolicyPatch
+	deps, err = gt.CheckOutdated(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, deps)
+}
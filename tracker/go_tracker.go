@@ -1,26 +1,165 @@
 package tracker
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
+	"io/fs"
 	"log"
-	"os/exec"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
+
+	"github.com/google/go-github/v48/github"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
 )
 
 // GoModules checks for outdated dependencies for Go modules.
 type GoModules struct {
-	module string
-	check  []GoModule
+	repo  string
+	cfg   GoModulesConfig
+	proxy *goProxy
+	cli   *github.Client
+
+	// work is the go.work file of the repository, or nil if there isn't
+	// one. It is read once by workspace.
+	workOnce sync.Once
+	work     *modfile.WorkFile
+	workErr  error
+
+	// goMods caches the go.mod files of module versions read while loading
+	// build lists, which are often shared by the modules of a repository.
+	goModsMut sync.Mutex
+	goMods    map[module.Version]*modfile.File
+}
+
+// goPruningVersion is the Go version from which go.mod files require every
+// module providing packages to the build, so the module graph is pruned.
+const goPruningVersion = "1.17"
+
+// goProxyConcurrency is the number of modules looked up from the module proxy
+// at once.
+const goProxyConcurrency = 8
+
+// GoModulesConfig configures which Go modules are checked.
+type GoModulesConfig struct {
+	// AllDirect checks every direct requirement of the module, in addition
+	// to modules listed in Modules.
+	AllDirect bool `yaml:"all_direct"`
+
+	// Indirect also checks indirect requirements when AllDirect is set.
+	Indirect bool `yaml:"indirect"`
+
+	// Exclude is a list of module path patterns which aren't checked when
+	// AllDirect is set. Patterns use the same syntax as GOPRIVATE: a glob
+	// which matches a module path or any of its path prefixes.
+	Exclude []string `yaml:"exclude"`
+
+	// Roots are the directories of the modules to check, relative to the
+	// repository. A directory ending in "/..." includes every module found
+	// under it. Defaults to the modules used by go.work, or the root of the
+	// repository if there is no go.work.
+	Roots []string `yaml:"roots"`
+
+	// Replace determines how modules discovered by AllDirect are checked
+	// when they are replaced in go.mod.
+	Replace ReplaceMode `yaml:"replace"`
+
+	// Modules are modules to check. Modules listed here are always checked,
+	// and their options take precedence over Options.
+	Modules []GoModule `yaml:"modules"`
+
+	// Options are used for modules discovered by AllDirect.
+	Options DependencyOptions `yaml:",inline"`
+}
+
+// UnmarshalYAML unmarshals a GoModulesConfig. The value can either be a list
+// of modules or the GoModulesConfig struct.
+func (c *GoModulesConfig) UnmarshalYAML(f func(v interface{}) error) error {
+	var (
+		listError   error
+		objectError error
+	)
+
+	// Try as a list of modules
+	var modules []GoModule
+	listError = f(&modules)
+	if listError == nil {
+		*c = GoModulesConfig{Modules: modules}
+		return nil
+	}
+
+	// Then a whole object
+	type goModulesConfig GoModulesConfig
+	var v goModulesConfig
+	objectError = f(&v)
+	if objectError == nil {
+		*c = GoModulesConfig(v)
+		return nil
+	}
+
+	return fmt.Errorf(
+		"could not parse go modules as a list (%s) or an object (%s)",
+		listError,
+		objectError,
+	)
+}
+
+// Enabled returns true if any modules should be checked.
+func (c *GoModulesConfig) Enabled() bool {
+	return c.AllDirect || len(c.Modules) > 0
 }
 
 // GoModule is an individual module to check.
 type GoModule struct {
-	Name    string            `yaml:"name"`
+	Name string `yaml:"name"`
+
+	// Replace determines how the module is checked when it is replaced in
+	// go.mod.
+	Replace ReplaceMode `yaml:"replace"`
+
 	Options DependencyOptions `yaml:",inline"`
+
+	// version is the version of the module selected in the build list, for
+	// modules which aren't required by go.mod.
+	version string
+}
+
+// ReplaceMode determines how a replaced Go module is checked.
+type ReplaceMode string
+
+// Supported ReplaceMode values. The default checks the replacement module,
+// unless it is a local directory, in which case the module is ignored.
+const (
+	// ReplaceModeReplacement checks the replacement module (such as a fork)
+	// for updates.
+	ReplaceModeReplacement ReplaceMode = "replacement"
+	// ReplaceModeOriginal checks the original module for updates, ignoring
+	// the replacement.
+	ReplaceModeOriginal ReplaceMode = "original"
+	// ReplaceModeIgnore doesn't check replaced modules.
+	ReplaceModeIgnore ReplaceMode = "ignore"
+)
+
+// UnmarshalYAML unmarshals a ReplaceMode, ensuring that it is valid.
+func (m *ReplaceMode) UnmarshalYAML(f func(v interface{}) error) error {
+	var s string
+	if err := f(&s); err != nil {
+		return err
+	}
+	switch v := ReplaceMode(s); v {
+	case ReplaceModeReplacement, ReplaceModeOriginal, ReplaceModeIgnore:
+		*m = v
+		return nil
+	default:
+		return fmt.Errorf("invalid replace mode %q: expected %q, %q, or %q", s, ReplaceModeReplacement, ReplaceModeOriginal, ReplaceModeIgnore)
+	}
 }
 
 // UnmarshalYAML unmarshals a GoModule. The value can either be a string
@@ -55,94 +194,718 @@ func (m *GoModule) UnmarshalYAML(f func(v interface{}) error) error {
 	)
 }
 
-// NewGoModules creates a new GoModules tracker.
-func NewGoModules(module string, check []GoModule) *GoModules {
+// NewGoModules creates a new GoModules tracker for the modules in the
+// repository at repo. Modules are looked up from the module proxy configured
+// by the GOPROXY, GONOPROXY, and GOPRIVATE environment variables. cli is
+// used to compare commits of modules pinned to pseudo-versions, and may be
+// nil.
+func NewGoModules(repo string, cfg GoModulesConfig, cli *github.Client) *GoModules {
 	return &GoModules{
-		module: module,
-		check:  check,
+		repo:  repo,
+		cfg:   cfg,
+		proxy: newGoProxyFromEnv(),
+		cli:   cli,
 	}
 }
 
 // CheckOutdated will return the list of go module dependencies that can be updated.
+// Dependencies used by multiple modules in the repository are only reported
+// once, with UsedBy listing each module.
 func (c *GoModules) CheckOutdated(ctx context.Context) ([]Dependency, error) {
+	roots, err := c.roots()
+	if err != nil {
+		return nil, err
+	}
+
 	var (
-		moduleNames = make([]string, len(c.check))
-		moduleMap   = make(map[string]GoModule)
+		outdated []Dependency
+		found    = make(map[string]bool)
+
+		// index maps a dependency name, kind, and latest version to its
+		// position in outdated.
+		index = make(map[[3]string]int)
 	)
-	for i := 0; i < len(c.check); i++ {
-		moduleNames[i] = c.check[i].Name
-		moduleMap[c.check[i].Name] = c.check[i]
+
+	for _, root := range roots {
+		check, err := c.modules(ctx, root)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", root, err)
+		}
+		for _, m := range check {
+			found[m.Name] = true
+		}
+
+		deps, err := c.checkModule(ctx, root, check)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", root, err)
+		}
+
+		for _, dep := range deps {
+			key := [3]string{dep.Name, string(dep.Kind), dep.LatestVersion}
+			if i, ok := index[key]; ok {
+				merged := &outdated[i]
+				merged.UsedBy = append(merged.UsedBy, root)
+				if semver.Compare(dep.CurrentVersion, merged.CurrentVersion) < 0 {
+					merged.CurrentVersion = dep.CurrentVersion
+				}
+				continue
+			}
+
+			dep.UsedBy = []string{root}
+			index[key] = len(outdated)
+			outdated = append(outdated, dep)
+		}
+	}
+
+	for _, m := range c.cfg.Modules {
+		if !found[m.Name] {
+			log.Printf("Ignoring dependency %s: not in the build list of any module", m.Name)
+		}
 	}
 
+	return outdated, nil
+}
+
+// checkModule returns the dependencies from check which can be updated in
+// the module at root. Modules are looked up concurrently.
+func (c *GoModules) checkModule(ctx context.Context, root string, check []GoModule) ([]Dependency, error) {
+	if len(check) == 0 {
+		return nil, nil
+	}
+
+	f, err := c.readGoMod(root)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		results = make([][]Dependency, len(check))
+		errs    = make([]error, len(check))
+
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, goProxyConcurrency)
+	)
+	for i, m := range check {
+		wg.Add(1)
+		go func(i int, m GoModule) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			results[i], errs[i] = c.checkRequirement(ctx, root, f, m)
+		}(i, m)
+	}
+	wg.Wait()
+
 	var outdated []Dependency
-	goArgs := append([]string{"list", "-mod=readonly", "-json", "-e", "-u", "-m"}, moduleNames...)
+	for i := range check {
+		if errs[i] != nil {
+			return nil, fmt.Errorf("%s: %w", check[i].Name, errs[i])
+		}
+		outdated = append(outdated, results[i]...)
+	}
+	return outdated, nil
+}
+
+// checkRequirement returns updates and notices for the requirement ref of the
+// module at root described by f.
+func (c *GoModules) checkRequirement(ctx context.Context, root string, f *modfile.File, ref GoModule) ([]Dependency, error) {
+	version := ref.version
+	for _, req := range f.Require {
+		if req.Mod.Path == ref.Name {
+			version = req.Mod.Version
+		}
+	}
 
-	var out bytes.Buffer
+	var (
+		path, name = ref.Name, ref.Name
 
-	cmd := exec.Command("go", goArgs...)
-	cmd.Stdout = &out
-	cmd.Dir = c.module
+		replacement string
+	)
+	if r, _ := c.replacement(root, f, ref.Name, version); r != nil {
+		local := r.New.Version == ""
+		replacement = strings.TrimSpace(r.New.Path + " " + r.New.Version)
 
-	if err := cmd.Run(); err != nil {
-		return nil, fmt.Errorf("failed running go command %q: %w", cmd, err)
+		switch ref.Replace {
+		case ReplaceModeIgnore:
+			return nil, nil
+		case ReplaceModeOriginal:
+			// Check the original module as if it wasn't replaced.
+		default:
+			if local {
+				if ref.Replace == ReplaceModeReplacement {
+					log.Printf("Ignoring dependency %s: replaced by local directory %s", ref.Name, r.New.Path)
+				}
+				return nil, nil
+			}
+			path, version = r.New.Path, r.New.Version
+			if path != ref.Name {
+				name = ref.Name + " => " + path
+			}
+		}
 	}
 
-	dec := json.NewDecoder(bytes.NewReader(out.Bytes()))
-	for {
-		var dep goListModule
-		if err := dec.Decode(&dep); err == io.EOF {
-			break
-		} else if err != nil {
-			return nil, fmt.Errorf("error decoding dependency: %w", err)
+	target, err := c.proxy.Module(ctx, path, version)
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	} else if err != nil {
+		log.Printf("Ingnoring dependency %s with error: %s", ref.Name, err)
+		return nil, nil
+	}
+
+	var outdated []Dependency
+	if len(target.Retracted) > 0 {
+		retracted := Dependency{
+			Name:           name,
+			Kind:           UpdateKindRetracted,
+			Reason:         strings.Join(target.Retracted, "; "),
+			CurrentVersion: target.Version,
+			Replacement:    replacement,
+		}
+		if target.Update != nil {
+			retracted.LatestVersion = target.Update.Version
 		}
+		outdated = append(outdated, retracted)
+	}
+	if target.Deprecated != "" {
+		outdated = append(outdated, Dependency{
+			Name:           name,
+			Kind:           UpdateKindDeprecated,
+			Reason:         target.Deprecated,
+			CurrentVersion: target.Version,
+			Replacement:    replacement,
+		})
+	}
+
+	latest, ok, err := c.newestVersion(ctx, ref, target)
+	if err == nil && module.IsPseudoVersion(target.Version) {
+		latest, ok, err = c.checkPseudoVersion(ctx, target, latest, ok)
+	}
+	if err != nil {
+		return nil, err
+	} else if ok {
+		latest.Name = name
+		latest.CurrentVersion = target.Version
+		latest.Replacement = replacement
+		outdated = append(outdated, latest)
+	}
+
+	major, ok, err := c.newestMajorVersion(ctx, ref, target)
+	if err != nil {
+		return nil, err
+	} else if ok {
+		outdated = append(outdated, Dependency{
+			Name:           name,
+			NewName:        major.NewName,
+			Kind:           UpdateKindMajorVersion,
+			CurrentVersion: target.Version,
+			LatestVersion:  major.LatestVersion,
+			PublishedAt:    major.PublishedAt,
+			Replacement:    replacement,
+		})
+	}
+
+	return outdated, nil
+}
+
+// replacement returns the replace directive which applies to version of the
+// module path in the module at root described by f, if any, along with the
+// directory which local replacement paths are relative to. Like the go
+// command, replacements in go.work take precedence over those in go.mod.
+func (c *GoModules) replacement(root string, f *modfile.File, path, version string) (*modfile.Replace, string) {
+	if work, _ := c.workspace(); work != nil {
+		if r := findReplace(work.Replace, path, version); r != nil {
+			return r, c.repo
+		}
+	}
+	return findReplace(f.Replace, path, version), c.rootDir(root)
+}
 
-		if dep.Error != nil {
-			log.Printf("Ingnoring dependency %s with error: %s", dep.Path, dep.Error)
+// findReplace returns the directive in replace which applies to version of
+// the module path, if any. Like the go command, a replacement of the specific
+// version takes precedence over one for all versions.
+func findReplace(replace []*modfile.Replace, path, version string) *modfile.Replace {
+	var found *modfile.Replace
+	for _, r := range replace {
+		switch {
+		case r.Old.Path != path:
 			continue
+		case r.Old.Version == version:
+			return r
+		case r.Old.Version == "":
+			found = r
 		}
+	}
+	return found
+}
+
+// newestVersion returns the newest version of mod permitted by the options
+// of ref, within the same module path.
+func (c *GoModules) newestVersion(ctx context.Context, ref GoModule, mod *moduleInfo) (Dependency, bool, error) {
+	// No update available, skip it
+	if mod.Update == nil {
+		return Dependency{}, false, nil
+	}
 
-		// No update available, skip it
-		if dep.Update == nil {
+	// Consider every version newer than the current one so an ignored
+	// latest version doesn't hide older updates. Like the Go command,
+	// prereleases are only considered when the available update is a
+	// prerelease.
+	allowPrerelease := semver.Prerelease(mod.Update.Version) != ""
+	available := []Dependency{{LatestVersion: mod.Update.Version}}
+	for _, v := range mod.Versions {
+		if v == mod.Update.Version || (semver.Prerelease(v) != "" && !allowPrerelease) {
 			continue
 		}
+		available = append(available, Dependency{LatestVersion: v})
+	}
+
+	published := c.published(ctx, func(string) string { return mod.Path })
+
+	available, err := ref.Options.sortReleases(available)
+	if err != nil {
+		return Dependency{}, false, err
+	}
+	latest, ok, err := ref.Options.newestUpdate(mod.Version, available, published)
+	if !ok || err != nil {
+		return Dependency{}, false, err
+	}
+	setPublishedAt(&latest, published)
+	return latest, true, nil
+}
+
+// newestMajorVersion returns the newest version of mod with a higher major
+// version module path than mod, such as example.com/mod/v3 for
+// example.com/mod/v2, which is permitted by the options of ref. Updates are
+// only found within the same module path, so higher major versions are found
+// by probing the module proxy for each following major version until one
+// doesn't exist. The module path of the returned version is set as NewName.
+func (c *GoModules) newestMajorVersion(ctx context.Context, ref GoModule, mod *moduleInfo) (Dependency, bool, error) {
+	switch ref.Options.UpdatePolicy {
+	case UpdatePolicyMinor, UpdatePolicyPatch:
+		// No major version would be reported; don't bother probing.
+		return Dependency{}, false, nil
+	}
 
-		// Check to see if we're ignoring this version
-		if ref, ok := moduleMap[dep.Path]; ok {
-			if ref.Options.IgnoreVersionPattern.Matches(dep.Update.Version) {
+	prefix, pathMajor, ok := module.SplitPathVersion(mod.Path)
+	if !ok {
+		return Dependency{}, false, nil
+	}
+	var (
+		sep   = "/v"
+		major = 1
+	)
+	if strings.HasPrefix(mod.Path, "gopkg.in/") {
+		sep = ".v"
+	}
+	if pathMajor != "" {
+		n, err := strconv.Atoi(pathMajor[len(sep):])
+		if err != nil {
+			return Dependency{}, false, nil
+		}
+		major = n
+	}
+
+	var (
+		available []Dependency
+		paths     = make(map[string]string)
+
+		// Don't suggest moving from a release to a prerelease of a new major
+		// version.
+		allowPrerelease = semver.Prerelease(mod.Version) != ""
+	)
+	for n := major + 1; ; n++ {
+		path := prefix + sep + strconv.Itoa(n)
+		versions, err := c.proxy.Versions(ctx, path)
+		if errors.Is(err, errModuleNotFound) || (err == nil && len(versions) == 0) {
+			// There is no module for this major version.
+			break
+		} else if err != nil {
+			log.Printf("Couldn't check %s for new major versions: %s", mod.Path, err)
+			break
+		}
+
+		for _, v := range versions {
+			if semver.Prerelease(v) != "" && !allowPrerelease {
 				continue
 			}
+			available = append(available, Dependency{LatestVersion: v})
+			paths[v] = path
 		}
+	}
 
-		outdated = append(outdated, Dependency{
-			Name:           dep.Path,
-			CurrentVersion: dep.Version,
-			LatestVersion:  dep.Update.Version,
-		})
+	published := c.published(ctx, func(version string) string { return paths[version] })
+
+	available, err := ref.Options.sortReleases(available)
+	if err != nil {
+		return Dependency{}, false, err
+	}
+	latest, ok, err := ref.Options.newestUpdate(mod.Version, available, published)
+	if !ok || err != nil {
+		return Dependency{}, false, err
 	}
+	setPublishedAt(&latest, published)
+	latest.NewName = paths[latest.LatestVersion]
+	return latest, true, nil
+}
 
-	return outdated, nil
+// published returns a publishedFunc which looks up the publish time of a
+// version of the module path returned by path.
+func (c *GoModules) published(ctx context.Context, path func(version string) string) publishedFunc {
+	return func(r Dependency) (time.Time, error) {
+		info, err := c.proxy.Info(ctx, path(r.LatestVersion), r.LatestVersion)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return info.Time, nil
+	}
+}
+
+// setPublishedAt looks up the publish time of latest with published, unless it
+// is already known from checking min_age. The publish time is only used in
+// issues, so failures are logged and leave PublishedAt unset.
+func setPublishedAt(latest *Dependency, published publishedFunc) {
+	if !latest.PublishedAt.IsZero() {
+		return
+	}
+	t, err := published(*latest)
+	if err != nil {
+		log.Printf("Couldn't get publish time of %s: %s", latest.LatestVersion, err)
+		return
+	}
+	latest.PublishedAt = t
+}
+
+// roots returns the directories of the modules to check, relative to the
+// repository. Directories are in the form "./path", or "." for the root of
+// the repository.
+func (c *GoModules) roots() ([]string, error) {
+	if len(c.cfg.Roots) == 0 {
+		// Default to the modules of the workspace, if there is one.
+		wf, err := c.workspace()
+		if err != nil {
+			return nil, err
+		} else if wf == nil {
+			return []string{"."}, nil
+		}
+
+		roots := make([]string, 0, len(wf.Use))
+		for _, use := range wf.Use {
+			roots = append(roots, cleanRoot(use.Path))
+		}
+		return roots, nil
+	}
+
+	var (
+		roots []string
+		seen  = make(map[string]bool)
+	)
+	for _, root := range c.cfg.Roots {
+		found := []string{cleanRoot(root)}
+
+		if dir := strings.TrimSuffix(root, "..."); dir != root {
+			var err error
+			found, err = findModules(c.repo, dir)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		for _, r := range found {
+			if !seen[r] {
+				seen[r] = true
+				roots = append(roots, r)
+			}
+		}
+	}
+	return roots, nil
+}
+
+// workspace returns the go.work file of the repository, or nil if there
+// isn't one.
+func (c *GoModules) workspace() (*modfile.WorkFile, error) {
+	c.workOnce.Do(func() {
+		workFile := filepath.Join(c.repo, "go.work")
+		b, err := os.ReadFile(workFile)
+		if errors.Is(err, os.ErrNotExist) {
+			return
+		} else if err != nil {
+			c.workErr = fmt.Errorf("failed to read go.work: %w", err)
+			return
+		}
+		c.work, err = modfile.ParseWork(workFile, b, nil)
+		if err != nil {
+			c.workErr = fmt.Errorf("failed to parse go.work: %w", err)
+		}
+	})
+	return c.work, c.workErr
+}
+
+// findModules returns the directories under dir (relative to repo) which
+// contain a go.mod file. Like the Go command, directories named vendor or
+// testdata and directories starting with "." or "_" are skipped.
+func findModules(repo, dir string) ([]string, error) {
+	var roots []string
+
+	start := filepath.Join(repo, dir)
+	err := filepath.WalkDir(start, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if file != start && (name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Name() != "go.mod" {
+			return nil
+		}
+
+		rel, err := filepath.Rel(repo, filepath.Dir(file))
+		if err != nil {
+			return err
+		}
+		roots = append(roots, cleanRoot(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find modules in %s: %w", dir, err)
+	}
+	return roots, nil
+}
+
+// cleanRoot normalizes a module directory to the form "./path", or "." for
+// the root of the repository. Absolute directories, such as those used by
+// go.work files outside of the repository, are only cleaned.
+func cleanRoot(root string) string {
+	if filepath.IsAbs(root) {
+		return filepath.Clean(root)
+	}
+	root = path.Clean(filepath.ToSlash(root))
+	if root == "." || strings.HasPrefix(root, "../") {
+		return root
+	}
+	return "./" + strings.TrimPrefix(root, "./")
 }
 
-// goListModule is the structure returned by "go list -json -u -m"
-// This struct was copied from the help page for "go help list".
-type goListModule struct {
-	Path      string             // module path
-	Version   string             // module version
-	Versions  []string           // available module versions (with -versions)
-	Replace   *goListModule      // replaced by this module
-	Time      *time.Time         // time version was created
-	Update    *goListModule      // available update, if any (with -u)
-	Main      bool               // is this the main module?
-	Indirect  bool               // is this module only an indirect dependency of main module?
-	Dir       string             // directory holding files for this module, if any
-	GoMod     string             // path to go.mod file used when loading this module, if any
-	GoVersion string             // go version used in module
-	Error     *goListModuleError // error loading module
+// rootDir returns the directory of the module at root.
+func (c *GoModules) rootDir(root string) string {
+	if filepath.IsAbs(root) {
+		return root
+	}
+	return filepath.Join(c.repo, root)
 }
 
-// This struct was copied from the help page for "go help list".
-type goListModuleError struct {
-	Err string // the error itself
+// modules returns the list of modules to check for the module at root.
+// Modules listed in the config are only checked if they are in the build list
+// of root.
+func (c *GoModules) modules(ctx context.Context, root string) ([]GoModule, error) {
+	f, err := c.readGoMod(root)
+	if err != nil {
+		return nil, err
+	}
+
+	required := make(map[string]*modfile.Require, len(f.Require))
+	for _, req := range f.Require {
+		required[req.Mod.Path] = req
+	}
+
+	var (
+		check  []GoModule
+		listed = make(map[string]bool, len(c.cfg.Modules))
+
+		// transitive are listed modules which may only be required by other
+		// modules, such as dependencies of go.mod files without module graph
+		// pruning.
+		transitive []GoModule
+	)
+	for _, m := range c.cfg.Modules {
+		listed[m.Name] = true
+		if required[m.Name] != nil {
+			check = append(check, m)
+		} else {
+			transitive = append(transitive, m)
+		}
+	}
+	// Since module graph pruning, go.mod requires every module providing
+	// packages to the build, so other modules don't need to be looked for.
+	if len(transitive) > 0 && !prunedGoMod(f) {
+		buildList, err := c.buildList(ctx, root, f)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range transitive {
+			if version, ok := buildList[m.Name]; ok {
+				m.version = version
+				check = append(check, m)
+			}
+		}
+	}
+	if !c.cfg.AllDirect {
+		return check, nil
+	}
+
+	for _, req := range f.Require {
+		switch {
+		case listed[req.Mod.Path]:
+			continue
+		case req.Indirect && !c.cfg.Indirect:
+			continue
+		case module.MatchPrefixPatterns(strings.Join(c.cfg.Exclude, ","), req.Mod.Path):
+			continue
+		}
+		listed[req.Mod.Path] = true
+		check = append(check, GoModule{Name: req.Mod.Path, Replace: c.cfg.Replace, Options: c.cfg.Options})
+	}
+	return check, nil
 }
 
-func (e *goListModuleError) Error() string { return e.Err }
+// buildList returns the version of each module selected in the build list of
+// the module at root described by f, like "go list -m all". The module graph
+// is read from the go.mod file of each required version through the module
+// proxy, applying the replace and exclude directives of f and go.work.
+//
+// Like the go command, the requirements of modules with pruned module graphs
+// are included, but not their transitive requirements. Requirements of
+// versions which can't be looked up are logged and skipped.
+func (c *GoModules) buildList(ctx context.Context, root string, f *modfile.File) (map[string]string, error) {
+	var (
+		selected = make(map[string]string)
+		loaded   = make(map[module.Version]bool)
+	)
+	// visit selects the versions of reqs, returning those whose requirements
+	// should be loaded next.
+	visit := func(reqs []*modfile.Require, load bool) []module.Version {
+		var next []module.Version
+	outer:
+		for _, req := range reqs {
+			for _, x := range f.Exclude {
+				if x.Mod == req.Mod {
+					continue outer
+				}
+			}
+
+			// Like minimal version selection, the highest required version
+			// of each module is selected.
+			if semver.Compare(req.Mod.Version, selected[req.Mod.Path]) > 0 {
+				selected[req.Mod.Path] = req.Mod.Version
+			}
+			if load && !loaded[req.Mod] {
+				loaded[req.Mod] = true
+				next = append(next, req.Mod)
+			}
+		}
+		return next
+	}
+
+	// The graph is loaded a level at a time, looking up the go.mod files of
+	// each level concurrently.
+	for queue := visit(f.Require, true); len(queue) > 0; {
+		var (
+			goMods = make([]*modfile.File, len(queue))
+
+			wg  sync.WaitGroup
+			sem = make(chan struct{}, goProxyConcurrency)
+		)
+		for i, m := range queue {
+			wg.Add(1)
+			go func(i int, m module.Version) {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+
+				goMod, err := c.requirementGoMod(ctx, root, f, m)
+				if err != nil {
+					if ctx.Err() == nil {
+						log.Printf("Ignoring requirements of %s@%s: %s", m.Path, m.Version, err)
+					}
+					return
+				}
+				goMods[i] = goMod
+			}(i, m)
+		}
+		wg.Wait()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		queue = nil
+		for _, goMod := range goMods {
+			if goMod != nil {
+				queue = append(queue, visit(goMod.Require, !prunedGoMod(goMod))...)
+			}
+		}
+	}
+	return selected, nil
+}
+
+// requirementGoMod returns the go.mod file of m, a module in the module graph
+// of the module at root described by f. Replacements are used instead of m,
+// reading the go.mod of local replacements from disk. go.mod files from the
+// module proxy are cached.
+func (c *GoModules) requirementGoMod(ctx context.Context, root string, f *modfile.File, m module.Version) (*modfile.File, error) {
+	r, base := c.replacement(root, f, m.Path, m.Version)
+	switch {
+	case r == nil:
+		return c.cachedGoMod(ctx, m)
+	case r.New.Version != "":
+		return c.cachedGoMod(ctx, r.New)
+	}
+
+	dir := r.New.Path
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(base, dir)
+	}
+	modFile := filepath.Join(dir, "go.mod")
+	b, err := os.ReadFile(modFile)
+	if err != nil {
+		return nil, err
+	}
+	return modfile.ParseLax(modFile, b, nil)
+}
+
+// cachedGoMod returns the go.mod file of m from the module proxy, caching it
+// for later lookups.
+func (c *GoModules) cachedGoMod(ctx context.Context, m module.Version) (*modfile.File, error) {
+	c.goModsMut.Lock()
+	goMod, ok := c.goMods[m]
+	c.goModsMut.Unlock()
+	if ok {
+		return goMod, nil
+	}
+
+	goMod, err := c.proxy.GoMod(ctx, m.Path, m.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	c.goModsMut.Lock()
+	defer c.goModsMut.Unlock()
+	if c.goMods == nil {
+		c.goMods = make(map[module.Version]*modfile.File)
+	}
+	c.goMods[m] = goMod
+	return goMod, nil
+}
+
+// prunedGoMod returns true if the module graph of the module described by f
+// is pruned, which is the case since Go 1.17.
+func prunedGoMod(f *modfile.File) bool {
+	return f.Go != nil && semver.Compare("v"+f.Go.Version, "v"+goPruningVersion) >= 0
+}
+
+// readGoMod parses the go.mod file of the module at root.
+func (c *GoModules) readGoMod(root string) (*modfile.File, error) {
+	modFile := filepath.Join(c.rootDir(root), "go.mod")
+	b, err := os.ReadFile(modFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read go.mod: %w", err)
+	}
+	f, err := modfile.Parse(modFile, b, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse go.mod: %w", err)
+	}
+	return f, nil
+}
@@ -1,10 +1,17 @@
 package tracker
 
 import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
 	"regexp"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
+	"golang.org/x/mod/modfile"
 	"gopkg.in/yaml.v2"
 )
 
@@ -31,6 +38,20 @@ func TestParseGoModule(t *testing.T) {
 				},
 			},
 		},
+		{
+			input: `{
+				"name": "github.com/prometheus/prometheus",
+				"update_policy": "minor",
+				"min_age": "72h",
+			}`,
+			expect: GoModule{
+				Name: "github.com/prometheus/prometheus",
+				Options: DependencyOptions{
+					UpdatePolicy: UpdatePolicyMinor,
+					MinAge:       72 * time.Hour,
+				},
+			},
+		},
 	}
 
 	for _, tc := range tt {
@@ -40,3 +61,366 @@ func TestParseGoModule(t *testing.T) {
 		require.Equal(t, tc.expect, actual)
 	}
 }
+
+func TestParseGoModulesConfig(t *testing.T) {
+	tt := []struct {
+		input  string
+		expect GoModulesConfig
+	}{
+		{
+			input: `["github.com/grafana/agent"]`,
+			expect: GoModulesConfig{
+				Modules: []GoModule{{Name: "github.com/grafana/agent"}},
+			},
+		},
+		{
+			input: `{
+				"all_direct": true,
+				"exclude": ["github.com/grafana/*"],
+				"update_policy": "minor",
+				"replace": "ignore",
+				"modules": [
+					"github.com/grafana/agent",
+					{"name": "github.com/grafana/loki", "replace": "original"},
+				],
+			}`,
+			expect: GoModulesConfig{
+				AllDirect: true,
+				Exclude:   []string{"github.com/grafana/*"},
+				Replace:   ReplaceModeIgnore,
+				Modules: []GoModule{
+					{Name: "github.com/grafana/agent"},
+					{Name: "github.com/grafana/loki", Replace: ReplaceModeOriginal},
+				},
+				Options: DependencyOptions{UpdatePolicy: UpdatePolicyMinor},
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		var actual GoModulesConfig
+		err := yaml.Unmarshal([]byte(tc.input), &actual)
+		require.NoError(t, err)
+		require.Equal(t, tc.expect, actual)
+	}
+}
+
+func TestParseGoModulesConfig_InvalidReplace(t *testing.T) {
+	var actual GoModulesConfig
+	err := yaml.Unmarshal([]byte(`{"all_direct": true, "replace": "fork"}`), &actual)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `invalid replace mode "fork"`)
+}
+
+func TestGoModules_AllDirect(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte(`module example.com/test
+
+go 1.21.0
+
+toolchain go1.22.1
+
+require (
+	github.com/grafana/agent v0.30.0
+	github.com/grafana/loki v1.6.1
+	github.com/prometheus/prometheus v1.8.2
+	gopkg.in/yaml.v2 v2.3.0
+	golang.org/x/mod v0.20.0 // indirect
+)
+`), 0644)
+	require.NoError(t, err)
+
+	opts := DependencyOptions{UpdatePolicy: UpdatePolicyPatch}
+	gm := NewGoModules(dir, GoModulesConfig{
+		AllDirect: true,
+		Exclude:   []string{"github.com/grafana/loki"},
+		Modules: []GoModule{
+			{Name: "github.com/prometheus/prometheus", Options: DependencyOptions{UpdatePolicy: UpdatePolicyMinor}},
+		},
+		Options: opts,
+	}, nil)
+
+	modules, err := gm.modules(context.Background(), ".")
+	require.NoError(t, err)
+	require.Equal(t, []GoModule{
+		{Name: "github.com/prometheus/prometheus", Options: DependencyOptions{UpdatePolicy: UpdatePolicyMinor}},
+		{Name: "github.com/grafana/agent", Options: opts},
+		{Name: "gopkg.in/yaml.v2", Options: opts},
+	}, modules)
+}
+
+func TestGoModules_BuildList(t *testing.T) {
+	proxy := t.TempDir()
+	writeProxyModule(t, proxy, "example.com/a", "v1.0.0")
+	writeProxyModule(t, proxy, "example.com/b", "v1.1.0", "v1.2.0", "v1.3.0")
+	writeProxyModule(t, proxy, "example.com/c", "v1.0.0")
+	writeProxyModule(t, proxy, "example.com/d", "v1.0.0")
+	for path, goMod := range map[string]string{
+		"example.com/a/@v/v1.0.0.mod": "module example.com/a\n\nrequire example.com/b v1.1.0\n",
+		// The module graph of example.com/c is pruned, so the requirements
+		// of example.com/d aren't loaded.
+		"example.com/c/@v/v1.0.0.mod": "module example.com/c\n\ngo 1.17\n\nrequire (\n\texample.com/b v1.2.0\n\texample.com/d v1.0.0\n)\n",
+		"example.com/d/@v/v1.0.0.mod": "module example.com/d\n\nrequire example.com/b v1.9.0\n",
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(proxy, filepath.FromSlash(path)), []byte(goMod), 0644))
+	}
+	t.Setenv("GOPROXY", "file://"+filepath.ToSlash(proxy))
+
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte(`module example.com/test
+
+go 1.16
+
+require (
+	example.com/a v1.0.0
+	example.com/c v1.0.0
+)
+`), 0644)
+	require.NoError(t, err)
+
+	// example.com/b is only required by other modules, and the highest
+	// required version is selected.
+	gm := NewGoModules(dir, GoModulesConfig{
+		Modules: []GoModule{{Name: "example.com/b"}, {Name: "example.com/missing"}},
+	}, nil)
+	modules, err := gm.modules(context.Background(), ".")
+	require.NoError(t, err)
+	require.Equal(t, []GoModule{{Name: "example.com/b", version: "v1.2.0"}}, modules)
+
+	deps, err := gm.CheckOutdated(context.Background())
+	require.NoError(t, err)
+	require.Len(t, deps, 1)
+	require.Equal(t, "example.com/b", deps[0].Name)
+	require.Equal(t, "v1.2.0", deps[0].CurrentVersion)
+	require.Equal(t, "v1.3.0", deps[0].LatestVersion)
+
+	// Pruned go.mod files require every module in the build, so other
+	// modules aren't looked for.
+	err = os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/test\n\ngo 1.17\n\nrequire example.com/a v1.0.0\n"), 0644)
+	require.NoError(t, err)
+	modules, err = gm.modules(context.Background(), ".")
+	require.NoError(t, err)
+	require.Empty(t, modules)
+}
+
+func TestGoModules_Workspace(t *testing.T) {
+	proxy := t.TempDir()
+	writeProxyModule(t, proxy, "example.com/a", "v1.0.0")
+	writeProxyModule(t, proxy, "example.com/b", "v1.1.0", "v1.2.0", "v1.3.0")
+	writeProxyModule(t, proxy, "example.com/fork", "v1.0.0")
+	for path, goMod := range map[string]string{
+		"example.com/a/@v/v1.0.0.mod":    "module example.com/a\n\nrequire example.com/b v1.1.0\n",
+		"example.com/fork/@v/v1.0.0.mod": "module example.com/a\n\nrequire example.com/b v1.2.0\n",
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(proxy, filepath.FromSlash(path)), []byte(goMod), 0644))
+	}
+	t.Setenv("GOPROXY", "file://"+filepath.ToSlash(proxy))
+
+	// The workspace uses a module outside of the repository by its
+	// absolute path, and replaces one of its requirements.
+	dir, other := t.TempDir(), t.TempDir()
+	err := os.WriteFile(filepath.Join(other, "go.mod"), []byte("module example.com/other\n\ngo 1.16\n\nrequire example.com/a v1.0.0\n"), 0644)
+	require.NoError(t, err)
+	err = os.WriteFile(filepath.Join(dir, "go.work"), []byte(fmt.Sprintf("go 1.21\n\nuse %q\n\nreplace example.com/a => example.com/fork v1.0.0\n", other)), 0644)
+	require.NoError(t, err)
+
+	gm := NewGoModules(dir, GoModulesConfig{Modules: []GoModule{{Name: "example.com/b"}}}, nil)
+	roots, err := gm.roots()
+	require.NoError(t, err)
+	require.Equal(t, []string{other}, roots)
+
+	deps, err := gm.CheckOutdated(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []Dependency{{
+		Name:           "example.com/b",
+		CurrentVersion: "v1.2.0",
+		LatestVersion:  "v1.3.0",
+		UsedBy:         []string{other},
+		PublishedAt:    time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+	}}, deps)
+}
+
+func TestGoModules_Roots(t *testing.T) {
+	dir := t.TempDir()
+	for _, mod := range []string{"go.mod", "operator/go.mod", "tools/go.mod", "tools/testdata/go.mod", "vendor/foo/go.mod"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, filepath.Dir(mod)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, mod), []byte("module example.com/test\n"), 0644))
+	}
+
+	gm := NewGoModules(dir, GoModulesConfig{}, nil)
+	roots, err := gm.roots()
+	require.NoError(t, err)
+	require.Equal(t, []string{"."}, roots)
+
+	gm = NewGoModules(dir, GoModulesConfig{Roots: []string{"./..."}}, nil)
+	roots, err = gm.roots()
+	require.NoError(t, err)
+	require.Equal(t, []string{".", "./operator", "./tools"}, roots)
+
+	err = os.WriteFile(filepath.Join(dir, "go.work"), []byte("go 1.21\n\nuse (\n\t.\n\t./operator\n)\n"), 0644)
+	require.NoError(t, err)
+
+	gm = NewGoModules(dir, GoModulesConfig{}, nil)
+	roots, err = gm.roots()
+	require.NoError(t, err)
+	require.Equal(t, []string{".", "./operator"}, roots)
+}
+
+func TestGoModules_MajorVersion(t *testing.T) {
+	proxy := t.TempDir()
+	writeProxyModule(t, proxy, "example.com/mod/v3", "v3.0.0", "v3.1.0")
+	writeProxyModule(t, proxy, "example.com/mod/v4", "v4.0.0-rc.1")
+
+	t.Setenv("GOPROXY", "file://"+filepath.ToSlash(proxy))
+
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/test\n\ngo 1.18\n"), 0644)
+	require.NoError(t, err)
+
+	gm := NewGoModules(dir, GoModulesConfig{}, nil)
+	mod := &moduleInfo{Path: "example.com/mod/v2", Version: "v2.3.0"}
+
+	latest, ok, err := gm.newestMajorVersion(context.Background(), GoModule{Name: mod.Path}, mod)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "v3.1.0", latest.LatestVersion)
+	require.Equal(t, "example.com/mod/v3", latest.NewName)
+	require.Equal(t, time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC), latest.PublishedAt)
+
+	// Ignore rules apply to new major versions.
+	ignored := GoModule{Name: mod.Path, Options: DependencyOptions{Ignore: mustParseConstraint(t, ">= 3.1.0")}}
+	latest, ok, err = gm.newestMajorVersion(context.Background(), ignored, mod)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "v3.0.0", latest.LatestVersion)
+
+	minor := GoModule{Name: mod.Path, Options: DependencyOptions{UpdatePolicy: UpdatePolicyMinor}}
+	_, ok, err = gm.newestMajorVersion(context.Background(), minor, mod)
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestGoModules_Deprecations(t *testing.T) {
+	proxy := t.TempDir()
+	writeProxyModule(t, proxy, "example.com/old", "v1.0.0", "v1.1.0")
+	latestMod := "// Deprecated: use example.com/new instead.\nmodule example.com/old\n\nretract v1.0.0 // Contains a data race.\n"
+	err := os.WriteFile(filepath.Join(proxy, "example.com", "old", "@v", "v1.1.0.mod"), []byte(latestMod), 0644)
+	require.NoError(t, err)
+
+	t.Setenv("GOPROXY", "file://"+filepath.ToSlash(proxy))
+
+	dir := t.TempDir()
+	err = os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/test\n\ngo 1.18\n\nrequire example.com/old v1.0.0\n"), 0644)
+	require.NoError(t, err)
+
+	gm := NewGoModules(dir, GoModulesConfig{Modules: []GoModule{{Name: "example.com/old"}}}, nil)
+	deps, err := gm.checkModule(context.Background(), ".", gm.cfg.Modules)
+	require.NoError(t, err)
+	require.Equal(t, []Dependency{
+		{Name: "example.com/old", Kind: UpdateKindRetracted, Reason: "Contains a data race.", CurrentVersion: "v1.0.0", LatestVersion: "v1.1.0"},
+		{Name: "example.com/old", Kind: UpdateKindDeprecated, Reason: "use example.com/new instead.", CurrentVersion: "v1.0.0"},
+		{Name: "example.com/old", CurrentVersion: "v1.0.0", LatestVersion: "v1.1.0", PublishedAt: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}, deps)
+}
+
+func TestFindReplace(t *testing.T) {
+	f, err := modfile.Parse("go.mod", []byte(`module example.com/test
+
+go 1.18
+
+replace example.com/mod v1.2.0 => ../mod
+
+replace example.com/mod => example.com/fork v1.0.0
+
+replace example.com/other v1.0.0 => example.com/other v1.0.1
+`), nil)
+	require.NoError(t, err)
+
+	tt := []struct {
+		path, version string
+		expect        string
+	}{
+		// A replacement of the specific version takes precedence, even if
+		// it comes first.
+		{path: "example.com/mod", version: "v1.2.0", expect: "../mod"},
+		{path: "example.com/mod", version: "v1.1.0", expect: "example.com/fork v1.0.0"},
+		{path: "example.com/other", version: "v1.0.0", expect: "example.com/other v1.0.1"},
+		{path: "example.com/other", version: "v1.1.0"},
+		{path: "example.com/unknown", version: "v1.0.0"},
+	}
+
+	for _, tc := range tt {
+		r := findReplace(f.Replace, tc.path, tc.version)
+		if tc.expect == "" {
+			require.Nil(t, r, "%s %s", tc.path, tc.version)
+			continue
+		}
+		require.NotNil(t, r, "%s %s", tc.path, tc.version)
+		require.Equal(t, tc.expect, strings.TrimSpace(r.New.Path+" "+r.New.Version))
+	}
+}
+
+func TestGoModules_Replace(t *testing.T) {
+	proxy := t.TempDir()
+	writeProxyModule(t, proxy, "example.com/mod", "v1.0.0", "v1.1.0")
+	writeProxyModule(t, proxy, "example.com/fork", "v1.0.0", "v1.2.0")
+	writeProxyModule(t, proxy, "example.com/local", "v1.0.0", "v1.1.0")
+	t.Setenv("GOPROXY", "file://"+filepath.ToSlash(proxy))
+
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte(`module example.com/test
+
+go 1.18
+
+require (
+	example.com/mod v1.0.0
+	example.com/local v1.0.0
+)
+
+replace example.com/mod => example.com/fork v1.0.0
+
+replace example.com/local => ./local
+`), 0644)
+	require.NoError(t, err)
+
+	published := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	fork := Dependency{
+		Name:           "example.com/mod => example.com/fork",
+		CurrentVersion: "v1.0.0",
+		LatestVersion:  "v1.2.0",
+		Replacement:    "example.com/fork v1.0.0",
+		PublishedAt:    published,
+	}
+
+	tt := []struct {
+		name   string
+		mode   ReplaceMode
+		expect []Dependency
+	}{
+		// Local replacements can't be checked, so they're skipped.
+		{name: "default", expect: []Dependency{fork}},
+		{name: "replacement", mode: ReplaceModeReplacement, expect: []Dependency{fork}},
+		{
+			name: "original",
+			mode: ReplaceModeOriginal,
+			expect: []Dependency{
+				{Name: "example.com/mod", CurrentVersion: "v1.0.0", LatestVersion: "v1.1.0", Replacement: "example.com/fork v1.0.0", PublishedAt: published},
+				{Name: "example.com/local", CurrentVersion: "v1.0.0", LatestVersion: "v1.1.0", Replacement: "./local", PublishedAt: published},
+			},
+		},
+		{name: "ignore", mode: ReplaceModeIgnore},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			check := []GoModule{
+				{Name: "example.com/mod", Replace: tc.mode},
+				{Name: "example.com/local", Replace: tc.mode},
+			}
+			gm := NewGoModules(dir, GoModulesConfig{Modules: check}, nil)
+			deps, err := gm.checkModule(context.Background(), ".", check)
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, deps)
+		})
+	}
+}
@@ -0,0 +1,300 @@
+package tracker
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+)
+
+// defaultGoProxy is the value of GOPROXY used by the go command when it is
+// unset.
+const defaultGoProxy = "https://proxy.golang.org,direct"
+
+// errModuleNotFound is returned by goProxy when a module or version doesn't
+// exist.
+var errModuleNotFound = errors.New("not found")
+
+// goProxy is a client for the Go module proxy protocol. It is configured like
+// the go command, with the GOPROXY, GONOPROXY, and GOPRIVATE environment
+// variables, and supports file:// proxies. Fetching modules directly from
+// version control isn't supported, so "direct" and modules matching
+// GONOPROXY can't be looked up.
+type goProxy struct {
+	proxies []goProxyEntry
+	noProxy string
+	cli     *http.Client
+}
+
+type goProxyEntry struct {
+	url string
+
+	// fallbackOnError is true when the entry is followed by "|", so that any
+	// error falls back to the next entry. Otherwise only "not found" errors
+	// do.
+	fallbackOnError bool
+}
+
+// newGoProxyFromEnv creates a goProxy configured from the environment.
+func newGoProxyFromEnv() *goProxy {
+	proxy := os.Getenv("GOPROXY")
+	if proxy == "" {
+		proxy = defaultGoProxy
+	}
+	noProxy := os.Getenv("GONOPROXY")
+	if noProxy == "" {
+		noProxy = os.Getenv("GOPRIVATE")
+	}
+	return newGoProxy(proxy, noProxy)
+}
+
+// newGoProxy creates a goProxy from a list of proxies in the format of
+// GOPROXY and a list of module path patterns in the format of GONOPROXY.
+func newGoProxy(proxy, noProxy string) *goProxy {
+	p := &goProxy{noProxy: noProxy}
+
+	for proxy != "" {
+		var entry goProxyEntry
+		if i := strings.IndexAny(proxy, ",|"); i >= 0 {
+			entry.url, entry.fallbackOnError = proxy[:i], proxy[i] == '|'
+			proxy = proxy[i+1:]
+		} else {
+			entry.url, proxy = proxy, ""
+		}
+		if entry.url = strings.TrimSpace(entry.url); entry.url != "" {
+			p.proxies = append(p.proxies, entry)
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.RegisterProtocol("file", http.NewFileTransport(http.Dir("/")))
+	p.cli = &http.Client{Transport: transport}
+
+	return p
+}
+
+// moduleInfo describes a version of a module and the versions available for
+// it, like the output of "go list -m -u -versions".
+type moduleInfo struct {
+	Path    string
+	Version string
+
+	// Versions are the tagged versions of the module from oldest to newest,
+	// excluding retracted versions.
+	Versions []string
+	// Update is the newest version of the module, if it is newer than
+	// Version. Like the go command, prereleases are only used when the module
+	// has no releases.
+	Update *moduleInfo
+
+	// Retracted explains why Version was retracted, if it was.
+	Retracted []string
+	// Deprecated is the deprecation message of the module, if any.
+	Deprecated string
+}
+
+// Module looks up a version of a module. Retractions and deprecations are
+// read from the go.mod of the latest version of the module.
+func (p *goProxy) Module(ctx context.Context, path, version string) (*moduleInfo, error) {
+	versions, err := p.Versions(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	latest := latestVersion(versions)
+	if latest == "" {
+		// The module has no tagged versions, so its latest version is a
+		// pseudo-version.
+		info, err := p.Latest(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+		latest = info.Version
+	}
+	goMod, err := p.GoMod(ctx, path, latest)
+	if err != nil {
+		return nil, err
+	}
+
+	mod := &moduleInfo{Path: path, Version: version}
+	if goMod.Module != nil {
+		mod.Deprecated = goMod.Module.Deprecated
+	}
+	mod.Retracted = retractions(goMod, version)
+	for _, v := range versions {
+		if len(retractions(goMod, v)) == 0 {
+			mod.Versions = append(mod.Versions, v)
+		}
+	}
+
+	if len(versions) > 0 {
+		latest = latestVersion(mod.Versions)
+	}
+	if latest != "" && semver.Compare(latest, version) > 0 {
+		mod.Update = &moduleInfo{Path: path, Version: latest}
+	}
+	return mod, nil
+}
+
+// latestVersion returns the newest release in versions, or the newest
+// prerelease if there are no releases. versions must be sorted.
+func latestVersion(versions []string) string {
+	for i := len(versions) - 1; i >= 0; i-- {
+		if semver.Prerelease(versions[i]) == "" {
+			return versions[i]
+		}
+	}
+	if len(versions) > 0 {
+		return versions[len(versions)-1]
+	}
+	return ""
+}
+
+// retractions returns the rationale of each retract directive in goMod which
+// covers version.
+func retractions(goMod *modfile.File, version string) []string {
+	var rationales []string
+	for _, r := range goMod.Retract {
+		if semver.Compare(r.Low, version) <= 0 && semver.Compare(version, r.High) <= 0 {
+			rationale := r.Rationale
+			if rationale == "" {
+				rationale = "retracted by module author"
+			}
+			rationales = append(rationales, rationale)
+		}
+	}
+	return rationales
+}
+
+// goProxyInfo is the format of the .info and @latest endpoints.
+type goProxyInfo struct {
+	Version string
+	Time    time.Time
+}
+
+// Versions returns the tagged versions of a module, sorted from oldest to
+// newest. Pseudo-versions aren't included.
+func (p *goProxy) Versions(ctx context.Context, path string) ([]string, error) {
+	b, err := p.get(ctx, path, "@v/list")
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []string
+	s := bufio.NewScanner(bytes.NewReader(b))
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) == 0 || !semver.IsValid(fields[0]) || module.IsPseudoVersion(fields[0]) {
+			continue
+		}
+		versions = append(versions, fields[0])
+	}
+	semver.Sort(versions)
+	return versions, s.Err()
+}
+
+// Latest returns the latest version of a module. Unlike Versions, it also
+// works for modules which don't have any tagged versions.
+func (p *goProxy) Latest(ctx context.Context, path string) (goProxyInfo, error) {
+	return p.info(ctx, path, "@latest")
+}
+
+// Info returns information about a version of a module.
+func (p *goProxy) Info(ctx context.Context, path, version string) (goProxyInfo, error) {
+	escaped, err := module.EscapeVersion(version)
+	if err != nil {
+		return goProxyInfo{}, err
+	}
+	return p.info(ctx, path, "@v/"+escaped+".info")
+}
+
+func (p *goProxy) info(ctx context.Context, path, file string) (goProxyInfo, error) {
+	b, err := p.get(ctx, path, file)
+	if err != nil {
+		return goProxyInfo{}, err
+	}
+	var info goProxyInfo
+	if err := json.Unmarshal(b, &info); err != nil {
+		return goProxyInfo{}, fmt.Errorf("failed to parse %s of %s: %w", file, path, err)
+	}
+	return info, nil
+}
+
+// GoMod returns the go.mod file of a version of a module.
+func (p *goProxy) GoMod(ctx context.Context, path, version string) (*modfile.File, error) {
+	escaped, err := module.EscapeVersion(version)
+	if err != nil {
+		return nil, err
+	}
+	b, err := p.get(ctx, path, "@v/"+escaped+".mod")
+	if err != nil {
+		return nil, err
+	}
+	return modfile.ParseLax(path+"@"+version+"/go.mod", b, nil)
+}
+
+// get retrieves file for the module path, trying each proxy in turn.
+func (p *goProxy) get(ctx context.Context, path, file string) ([]byte, error) {
+	if module.MatchPrefixPatterns(p.noProxy, path) {
+		return nil, fmt.Errorf("%s matches GONOPROXY or GOPRIVATE, but fetching modules directly isn't supported", path)
+	}
+	escaped, err := module.EscapePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	lastErr := fmt.Errorf("GOPROXY doesn't list any proxies")
+	for _, entry := range p.proxies {
+		switch entry.url {
+		case "off":
+			return nil, fmt.Errorf("module lookup disabled by GOPROXY=off")
+		case "direct":
+			if errors.Is(lastErr, errModuleNotFound) {
+				return nil, lastErr
+			}
+			return nil, fmt.Errorf("fetching modules directly isn't supported; set GOPROXY to a module proxy")
+		}
+
+		b, err := p.fetch(ctx, strings.TrimSuffix(entry.url, "/")+"/"+escaped+"/"+file)
+		if err == nil {
+			return b, nil
+		}
+		lastErr = err
+		if !entry.fallbackOnError && !errors.Is(err, errModuleNotFound) {
+			return nil, err
+		}
+	}
+	return nil, lastErr
+}
+
+func (p *goProxy) fetch(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.cli.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return io.ReadAll(resp.Body)
+	case http.StatusNotFound, http.StatusGone:
+		return nil, fmt.Errorf("%s: %w", url, errModuleNotFound)
+	default:
+		return nil, fmt.Errorf("%s: unexpected status %s", url, resp.Status)
+	}
+}
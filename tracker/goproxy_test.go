@@ -0,0 +1,96 @@
+package tracker
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoProxy_Module(t *testing.T) {
+	proxy := t.TempDir()
+	writeProxyModule(t, proxy, "example.com/mod", "v1.0.0", "v1.1.0", "v1.2.0", "v1.3.0-rc.1", "v1.3.0")
+	latestMod := "module example.com/mod\n\nretract (\n\tv1.3.0 // Published too early.\n\tv1.0.0\n)\n"
+	err := os.WriteFile(filepath.Join(proxy, "example.com", "mod", "@v", "v1.3.0.mod"), []byte(latestMod), 0644)
+	require.NoError(t, err)
+
+	p := newGoProxy("file://"+filepath.ToSlash(proxy), "")
+
+	mod, err := p.Module(context.Background(), "example.com/mod", "v1.0.0")
+	require.NoError(t, err)
+	require.Equal(t, &moduleInfo{
+		Path:      "example.com/mod",
+		Version:   "v1.0.0",
+		Versions:  []string{"v1.1.0", "v1.2.0", "v1.3.0-rc.1"},
+		Update:    &moduleInfo{Path: "example.com/mod", Version: "v1.2.0"},
+		Retracted: []string{"retracted by module author"},
+	}, mod)
+
+	info, err := p.Info(context.Background(), "example.com/mod", "v1.2.0")
+	require.NoError(t, err)
+	require.Equal(t, "v1.2.0", info.Version)
+	require.Equal(t, 2022, info.Time.Year())
+}
+
+func TestGoProxy_Fallback(t *testing.T) {
+	var (
+		empty = "file://" + filepath.ToSlash(t.TempDir())
+		full  = t.TempDir()
+	)
+	writeProxyModule(t, full, "example.com/mod", "v1.0.0")
+	fullURL := "file://" + filepath.ToSlash(full)
+
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "oops", http.StatusInternalServerError)
+	}))
+	t.Cleanup(broken.Close)
+
+	tt := []struct {
+		proxy, noProxy string
+		expectError    string
+	}{
+		{proxy: empty + "," + fullURL},
+		{proxy: empty + ",direct", expectError: "not found"},
+		{proxy: "direct", expectError: "fetching modules directly isn't supported"},
+		{proxy: "off", expectError: "disabled by GOPROXY=off"},
+		{proxy: broken.URL + "|" + fullURL},
+		{proxy: broken.URL + "," + fullURL, expectError: "unexpected status 500"},
+		{proxy: fullURL, noProxy: "example.com", expectError: "matches GONOPROXY or GOPRIVATE"},
+	}
+
+	for _, tc := range tt {
+		t.Run(fmt.Sprintf("%s %s", tc.proxy, tc.noProxy), func(t *testing.T) {
+			p := newGoProxy(tc.proxy, tc.noProxy)
+			versions, err := p.Versions(context.Background(), "example.com/mod")
+			if tc.expectError != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tc.expectError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, []string{"v1.0.0"}, versions)
+		})
+	}
+}
+
+// writeProxyModule writes versions of the module path to a GOPROXY directory.
+func writeProxyModule(t *testing.T, proxy, path string, versions ...string) {
+	t.Helper()
+
+	dir := filepath.Join(proxy, filepath.FromSlash(path), "@v")
+	require.NoError(t, os.MkdirAll(dir, 0755))
+
+	var list string
+	for _, v := range versions {
+		list += v + "\n"
+		info := fmt.Sprintf(`{"Version":%q,"Time":"2022-01-01T00:00:00Z"}`, v)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, v+".info"), []byte(info), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, v+".mod"), []byte("module "+path+"\n"), 0644))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "list"), []byte(list), 0644))
+}
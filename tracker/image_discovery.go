@@ -0,0 +1,284 @@
+package tracker
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// yamlImageRegexp matches an image field in YAML, such as those of
+// Kubernetes containers or compose services, capturing its value.
+var yamlImageRegexp = regexp.MustCompile(`^\s*(?:-\s+)?image:\s*(.+)$`)
+
+// ImageDiscovery checks container images referenced by Dockerfiles and YAML
+// manifests in the repository, so they don't need to be listed in
+// container_images.
+type ImageDiscovery struct {
+	repo   string
+	cfg    ImageDiscoveryConfig
+	images *ContainerImages
+}
+
+// ImageDiscoveryConfig configures discovering container images.
+type ImageDiscoveryConfig struct {
+	Enabled bool `yaml:"enabled"`
+
+	// Exclude are globs of files or directories, relative to the repository,
+	// which aren't scanned. Directories named .git are never scanned.
+	Exclude []string `yaml:"exclude"`
+
+	Options DependencyOptions `yaml:",inline"`
+}
+
+// imageUsage is a reference to a container image in a file.
+type imageUsage struct {
+	// Ref is the image and tag, such as grafana/grafana:10.2.0.
+	Ref  string
+	Line int
+}
+
+// NewImageDiscovery creates a new ImageDiscovery tracker for images in
+// repo. Registries are authenticated using credentials from the docker
+// config file.
+func NewImageDiscovery(repo string, cfg ImageDiscoveryConfig) *ImageDiscovery {
+	return &ImageDiscovery{repo: repo, cfg: cfg, images: NewContainerImages(nil)}
+}
+
+// CheckOutdated will return the list of discovered container images that can
+// be updated. Images with the same update are reported once, such as
+// alpine:3.18 and docker.io/library/alpine:3.19 when alpine:3.20 is available,
+// with UsedBy listing the files and lines referencing them. Images which can't
+// be checked, such as those in registries which can't be accessed, are logged
+// and skipped.
+func (c *ImageDiscovery) CheckOutdated(ctx context.Context) ([]Dependency, error) {
+	images, err := c.discover()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		outdated []Dependency
+		used     [][]usage
+
+		// checked maps an image and tag to the position of its update in
+		// outdated, or -1 if it is up to date or couldn't be checked.
+		checked = make(map[[2]string]int)
+		// index maps an image and latest tag to its position in outdated.
+		index = make(map[[2]string]int)
+	)
+	for _, img := range images {
+		source := fmt.Sprintf("%s:%d", img.File, img.Line)
+
+		// Images are keyed by their repository, since the same image can be
+		// written in several ways, such as alpine and docker.io/library/alpine.
+		ref, err := parseImageRef(img.Image)
+		if err != nil {
+			log.Printf("Ignoring image %s:%s in %s: %s", img.Image, img.Version, source, err)
+			continue
+		}
+		repository := ref.Registry + "/" + ref.Repository
+
+		key := [2]string{repository, img.Version}
+		i, ok := checked[key]
+		if !ok {
+			i = -1
+			latest, ok, err := c.images.checkImage(ctx, img)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil, ctx.Err()
+				}
+				log.Printf("Ignoring image %s:%s in %s: %s", img.Image, img.Version, source, err)
+			} else if ok {
+				latestKey := [2]string{repository, latest.LatestVersion}
+				if j, ok := index[latestKey]; ok {
+					i = j
+				} else {
+					i = len(outdated)
+					index[latestKey] = i
+					outdated = append(outdated, latest)
+					used = append(used, nil)
+				}
+			}
+			checked[key] = i
+		}
+		if i < 0 {
+			continue
+		}
+
+		used[i] = append(used[i], usage{Source: source, Version: img.Version})
+		if olderTag(img.Version, outdated[i].CurrentVersion) {
+			outdated[i].CurrentVersion = img.Version
+		}
+	}
+
+	for i := range outdated {
+		outdated[i].setUsedBy(used[i])
+	}
+	return outdated, nil
+}
+
+// olderTag returns true if the version of tag a is older than that of tag b.
+// Both tags must start with a version.
+func olderTag(a, b string) bool {
+	va, _ := parseDotted(containerTagRegexp.FindStringSubmatch(a)[2])
+	vb, _ := parseDotted(containerTagRegexp.FindStringSubmatch(b)[2])
+	return va.Compare(vb) < 0
+}
+
+// discover returns the container images referenced in the repository.
+// Images without a tag, pinned to a digest, or whose tag isn't a version such
+// as latest, are skipped.
+func (c *ImageDiscovery) discover() ([]ContainerImage, error) {
+	var images []ContainerImage
+
+	err := walkRepo(c.repo, c.cfg.Exclude, func(rel string, b []byte) error {
+		var usages []imageUsage
+		switch name := path.Base(rel); {
+		case isDockerfile(name):
+			usages = dockerfileImages(b)
+		case strings.HasSuffix(name, ".yaml") || strings.HasSuffix(name, ".yml"):
+			usages = yamlImages(b)
+		}
+
+		for _, u := range usages {
+			if strings.Contains(u.Ref, "@") {
+				continue
+			}
+			image, tag := splitImageTag(u.Ref)
+			if tag == "" || !containerTagRegexp.MatchString(tag) {
+				continue
+			}
+			images = append(images, ContainerImage{
+				Image:   image,
+				Version: tag,
+				Options: c.cfg.Options,
+				File:    rel,
+				Line:    u.Line,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return images, nil
+}
+
+// isDockerfile returns true if a file name is a Dockerfile, such as
+// Dockerfile, Dockerfile.build, or app.Dockerfile.
+func isDockerfile(name string) bool {
+	lower := strings.ToLower(name)
+	return lower == "dockerfile" || lower == "containerfile" ||
+		strings.HasPrefix(lower, "dockerfile.") || strings.HasSuffix(lower, ".dockerfile")
+}
+
+// dockerfileImages returns the images used in FROM instructions of a
+// Dockerfile. Variables in FROM instructions are expanded using the
+// defaults of ARG instructions before the first FROM. References to earlier
+// build stages, scratch, and images which can't be fully expanded are
+// skipped.
+func dockerfileImages(dockerfile []byte) []imageUsage {
+	var (
+		usages []imageUsage
+
+		args     = make(map[string]string)
+		stages   = make(map[string]bool)
+		seenFrom bool
+		line     int
+	)
+
+	s := bufio.NewScanner(bytes.NewReader(dockerfile))
+	for s.Scan() {
+		line++
+
+		fields := strings.Fields(s.Text())
+		if len(fields) < 2 {
+			continue
+		}
+
+		switch strings.ToUpper(fields[0]) {
+		case "ARG":
+			// Only arguments declared before the first FROM can be used in
+			// FROM instructions.
+			if seenFrom {
+				continue
+			}
+			for _, arg := range fields[1:] {
+				if name, value, ok := strings.Cut(arg, "="); ok {
+					args[name] = strings.Trim(value, `"'`)
+				}
+			}
+
+		case "FROM":
+			seenFrom = true
+
+			// Skip flags such as --platform.
+			i := 1
+			for i < len(fields) && strings.HasPrefix(fields[i], "--") {
+				i++
+			}
+			if i == len(fields) {
+				continue
+			}
+			var undefined bool
+			image := os.Expand(fields[i], func(name string) string {
+				// Support ${NAME:-default}.
+				name, def, hasDefault := strings.Cut(name, ":-")
+				if v := args[name]; v != "" {
+					return v
+				} else if hasDefault {
+					return def
+				}
+				undefined = true
+				return ""
+			})
+			skip := undefined || image == "" || strings.EqualFold(image, "scratch") || stages[strings.ToLower(image)]
+
+			// Later FROM instructions may refer to this stage by name.
+			if i+2 < len(fields) && strings.EqualFold(fields[i+1], "AS") {
+				stages[strings.ToLower(fields[i+2])] = true
+			}
+			if !skip {
+				usages = append(usages, imageUsage{Ref: image, Line: line})
+			}
+		}
+	}
+	return usages
+}
+
+// yamlImages returns the values of image fields in a YAML file, such as a
+// Kubernetes manifest or compose file. Values containing templates or
+// variables are skipped.
+func yamlImages(b []byte) []imageUsage {
+	var (
+		usages []imageUsage
+		line   int
+	)
+
+	s := bufio.NewScanner(bytes.NewReader(b))
+	for s.Scan() {
+		line++
+
+		m := yamlImageRegexp.FindStringSubmatch(s.Text())
+		if m == nil {
+			continue
+		}
+		value := m[1]
+		if i := strings.Index(value, " #"); i >= 0 {
+			value = value[:i]
+		}
+		value = strings.Trim(strings.TrimSpace(value), `"'`)
+
+		if value == "" || strings.ContainsAny(value, "${} ") {
+			continue
+		}
+		usages = append(usages, imageUsage{Ref: value, Line: line})
+	}
+	return usages
+}
@@ -0,0 +1,98 @@
+package tracker
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDockerfileImages(t *testing.T) {
+	dockerfile := `ARG GO_VERSION=1.22.1
+ARG ALPINE_VERSION="3.19"
+ARG BASE
+
+FROM --platform=$BUILDPLATFORM golang:${GO_VERSION}-alpine AS build
+ARG ALPINE_VERSION=3.18
+RUN go build ./...
+
+FROM build AS test
+FROM ${BASE}
+FROM scratch
+FROM docker.io/library/alpine:${ALPINE_VERSION}
+FROM grafana/agent:${AGENT_VERSION:-v0.40.0}
+`
+
+	require.Equal(t, []imageUsage{
+		{Ref: "golang:1.22.1-alpine", Line: 5},
+		{Ref: "docker.io/library/alpine:3.19", Line: 12},
+		{Ref: "grafana/agent:v0.40.0", Line: 13},
+	}, dockerfileImages([]byte(dockerfile)))
+}
+
+func TestYAMLImages(t *testing.T) {
+	manifest := `apiVersion: apps/v1
+kind: Deployment
+spec:
+  template:
+    spec:
+      containers:
+        - name: grafana
+          image: "grafana/grafana:10.2.0" # pinned
+        - image: ghcr.io/grafana/agent:v0.40.0
+          name: agent
+        - name: templated
+          image: {{ .Values.image }}
+        - name: compose
+          image: ${REGISTRY}/app:1.0.0
+`
+
+	require.Equal(t, []imageUsage{
+		{Ref: "grafana/grafana:10.2.0", Line: 8},
+		{Ref: "ghcr.io/grafana/agent:v0.40.0", Line: 9},
+	}, yamlImages([]byte(manifest)))
+}
+
+func TestImageDiscovery_CheckOutdated(t *testing.T) {
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	registry := strings.TrimPrefix(srv.URL, "http://")
+
+	mux.HandleFunc("/v2/team/app/tags/list", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"name": "team/app", "tags": ["1.2.0", "1.3.0", "latest"]}`)
+	})
+	t.Setenv("DOCKER_CONFIG", t.TempDir())
+
+	repo := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(repo, "deploy"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(repo, "vendor"), 0755))
+	files := map[string]string{
+		"Dockerfile":        "ARG APP_VERSION=1.2.0\nFROM " + registry + "/team/app:${APP_VERSION}\n",
+		"deploy/app.yaml":   "containers:\n  - image: " + registry + "/team/app:1.2.0\n  - image: " + registry + "/team/app:latest\n",
+		"deploy/old.yaml":   "image: " + registry + "/team/app:1.1.0\n",
+		"deploy/other.yaml": "image: " + registry + "/team/missing:1.0.0\n",
+		"vendor/app.yaml":   "image: " + registry + "/team/app:1.0.0\n",
+	}
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(repo, name), []byte(content), 0644))
+	}
+
+	d := NewImageDiscovery(repo, ImageDiscoveryConfig{Enabled: true, Exclude: []string{"vendor"}})
+	deps, err := d.CheckOutdated(context.Background())
+	require.NoError(t, err)
+	// Tags with the same update are reported once, with the oldest tag as
+	// the current version.
+	require.Equal(t, []Dependency{{
+		Name:           registry + "/team/app",
+		CurrentVersion: "1.1.0",
+		LatestVersion:  "1.3.0",
+		UsedBy:         []string{"Dockerfile:2 at 1.2.0", "deploy/app.yaml:2 at 1.2.0", "deploy/old.yaml:1 at 1.1.0"},
+	}}, deps)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"regexp"
 	"strings"
 	"text/template"
 
@@ -18,6 +19,10 @@ type IssueCreator struct {
 	cli *github.Client
 
 	titleTmpl, bodyTmpl *template.Template
+
+	// deprecationTitleTmpl and deprecationBodyTmpl are used for retracted or
+	// deprecated dependencies.
+	deprecationTitleTmpl, deprecationBodyTmpl *template.Template
 }
 
 // NewIssueCreator creates a new issue creator.
@@ -31,12 +36,24 @@ func NewIssueCreator(c *Config, cli *github.Client) (*IssueCreator, error) {
 		return nil, fmt.Errorf("failed to parse issue body template: %w", err)
 	}
 
+	deprecationTitleTmpl, err := template.New("deprecation_title").Parse(c.DeprecationTitleTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse deprecation title template: %w", err)
+	}
+	deprecationBodyTmpl, err := template.New("deprecation_body").Parse(c.DeprecationTextTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse deprecation body template: %w", err)
+	}
+
 	return &IssueCreator{
 		c:   c,
 		cli: cli,
 
 		titleTmpl: titleTmpl,
 		bodyTmpl:  bodyTmpl,
+
+		deprecationTitleTmpl: deprecationTitleTmpl,
+		deprecationBodyTmpl:  deprecationBodyTmpl,
 	}, nil
 }
 
@@ -79,7 +96,7 @@ func (c *IssueCreator) FindIssue(ctx context.Context, dep Dependency) (*github.I
 		`"%s" repo:"%s" label:"%s" in:title`,
 		expectedIssue.GetTitle(),
 		c.c.IssueRepository,
-		c.c.OutdatedLabel,
+		c.label(dep),
 	)
 	res, _, err := c.cli.Search.Issues(ctx, query, &github.SearchOptions{})
 	if err != nil {
@@ -98,14 +115,19 @@ func (c *IssueCreator) issueRequest(dep Dependency) (*github.IssueRequest, error
 	var (
 		titleBuilder strings.Builder
 		bodyBuilder  strings.Builder
+
+		titleTmpl, bodyTmpl = c.titleTmpl, c.bodyTmpl
 	)
+	if dep.Kind.IsDeprecation() {
+		titleTmpl, bodyTmpl = c.deprecationTitleTmpl, c.deprecationBodyTmpl
+	}
 
-	err := c.titleTmpl.Execute(&titleBuilder, dep)
+	err := titleTmpl.Execute(&titleBuilder, dep)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate issue title: %w", err)
 	}
 
-	err = c.bodyTmpl.Execute(&bodyBuilder, dep)
+	err = bodyTmpl.Execute(&bodyBuilder, dep)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate issue body: %w", err)
 	}
@@ -118,10 +140,18 @@ func (c *IssueCreator) issueRequest(dep Dependency) (*github.IssueRequest, error
 	return &github.IssueRequest{
 		Title:  &title,
 		Body:   &body,
-		Labels: &[]string{c.c.OutdatedLabel},
+		Labels: &[]string{c.label(dep)},
 	}, nil
 }
 
+// label returns the label to attach to the issue for dep.
+func (c *IssueCreator) label(dep Dependency) string {
+	if dep.Kind.IsDeprecation() {
+		return c.c.DeprecationLabel
+	}
+	return c.c.OutdatedLabel
+}
+
 func parseGithubRepo(fullRepo string) (owner, repo string, err error) {
 	parts := strings.SplitN(fullRepo, "/", 2)
 	if len(parts) != 2 {
@@ -131,15 +161,28 @@ func parseGithubRepo(fullRepo string) (owner, repo string, err error) {
 	return parts[0], parts[1], nil
 }
 
-// CloseOutdated closes issues for dep that are older than latest.
+// CloseOutdated closes issues for dep that are older than latest. Issues for
+// retracted or deprecated dependencies are never closed.
 func (c *IssueCreator) CloseOutdated(ctx context.Context, latest *github.Issue, dep Dependency) error {
+	if dep.Kind.IsDeprecation() {
+		return nil
+	}
+
+	// Render the title with placeholders for the parts which change between
+	// updates. New major versions may also have moved to another module path
+	// since the issue was created.
 	genericDep := dep
-	genericDep.LatestVersion = "*"
+	genericDep.LatestVersion = titleWildcard
+	if dep.NewName != "" {
+		genericDep.NewName = titleWildcard
+	}
 
 	genericIss, err := c.issueRequest(genericDep)
 	if err != nil {
 		return fmt.Errorf("failed to generate oudated issue pattern: %w", err)
 	}
+	genericTitle := genericIss.GetTitle()
+	titlePattern := outdatedTitlePattern(genericTitle)
 
 	repoOwner, repoName, err := parseGithubRepo(c.c.IssueRepository)
 	if err != nil {
@@ -148,7 +191,7 @@ func (c *IssueCreator) CloseOutdated(ctx context.Context, latest *github.Issue,
 
 	searchQuery := fmt.Sprintf(
 		`"%s" repo:"%s" label:"%s" in:title is:open`,
-		genericIss.GetTitle(),
+		strings.ReplaceAll(genericTitle, titleWildcard, "*"),
 		c.c.IssueRepository,
 		c.c.OutdatedLabel,
 	)
@@ -161,6 +204,11 @@ func (c *IssueCreator) CloseOutdated(ctx context.Context, latest *github.Issue,
 		if iss.GetID() == latest.GetID() || iss.GetID() == 0 {
 			continue
 		}
+		// Search matches titles loosely, which includes issues of other
+		// dependencies and other kinds of updates to dep.
+		if !titlePattern.MatchString(iss.GetTitle()) {
+			continue
+		}
 
 		var (
 			closed      = "closed"
@@ -186,3 +234,21 @@ func (c *IssueCreator) CloseOutdated(ctx context.Context, latest *github.Issue,
 
 	return nil
 }
+
+// titleWildcard is a placeholder in issue titles for a version or module path
+// which differs between updates to a dependency.
+const titleWildcard = "\x00"
+
+// outdatedTitlePattern returns a regex matching the titles of issues for
+// other updates of the same dependency and kind, given a title rendered with
+// titleWildcard placeholders. A placeholder matches a single version or module
+// path, so the title of a new major version, like "Update foo to foo/v2@v2.0.0",
+// doesn't match the pattern "Update foo to *" of updates within the current
+// major version.
+func outdatedTitlePattern(title string) *regexp.Regexp {
+	parts := strings.Split(title, titleWildcard)
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	return regexp.MustCompile("^" + strings.Join(parts, `[^\s@]+`) + "$")
+}
@@ -0,0 +1,76 @@
+package tracker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-github/v48/github"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIssueCreator_CloseOutdated(t *testing.T) {
+	titles := map[int]string{
+		1: "Update github.com/foo/bar to v1.2.0",
+		2: "Update github.com/foo/bar to github.com/foo/bar/v3@v3.0.0",
+		3: "Update github.com/foo/bar to v1.1.0",
+		4: "Update github.com/foo/bar to github.com/foo/bar/v2@v2.1.0",
+		5: "Update github.com/foo/bar/baz to v1.0.0",
+	}
+
+	var (
+		mut    sync.Mutex
+		closed []int
+	)
+	cli := newTestGithubClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/search/issues":
+			// Search matches titles loosely, so return every issue.
+			res := github.IssuesSearchResult{}
+			for i := 1; i <= len(titles); i++ {
+				res.Issues = append(res.Issues, &github.Issue{
+					ID:     github.Int64(int64(i)),
+					Number: github.Int(i),
+					Title:  github.String(titles[i]),
+				})
+			}
+			_ = json.NewEncoder(w).Encode(res)
+		case r.Method == http.MethodPatch:
+			var number int
+			_, _ = fmt.Sscanf(r.URL.Path, "/repos/grafana/agent/issues/%d", &number)
+			mut.Lock()
+			closed = append(closed, number)
+			mut.Unlock()
+			fmt.Fprint(w, `{}`)
+		case strings.HasSuffix(r.URL.Path, "/comments"):
+			fmt.Fprint(w, `{}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	cfg := DefaultConfig
+	cfg.IssueRepository = "grafana/agent"
+	creator, err := NewIssueCreator(&cfg, cli)
+	require.NoError(t, err)
+
+	var (
+		update = Dependency{Name: "github.com/foo/bar", CurrentVersion: "v1.0.0", LatestVersion: "v1.2.0"}
+		major  = Dependency{Name: "github.com/foo/bar", CurrentVersion: "v1.0.0", LatestVersion: "v3.0.0", NewName: "github.com/foo/bar/v3", Kind: UpdateKindMajorVersion}
+	)
+	for i, dep := range []Dependency{update, major} {
+		iss, err := creator.issueRequest(dep)
+		require.NoError(t, err)
+		require.Equal(t, titles[i+1], iss.GetTitle())
+
+		latest := &github.Issue{ID: github.Int64(int64(i + 1)), Number: github.Int(i + 1)}
+		require.NoError(t, creator.CloseOutdated(context.Background(), latest, dep))
+	}
+
+	// Only the older issue of each kind is closed.
+	require.Equal(t, []int{3, 4}, closed)
+}
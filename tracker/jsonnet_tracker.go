@@ -0,0 +1,224 @@
+package tracker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Jsonnet checks for outdated dependencies vendored with jsonnet-bundler.
+type Jsonnet struct {
+	repo  string
+	check []JsonnetFile
+}
+
+// JsonnetFile is a jsonnetfile.json whose dependencies should be checked.
+type JsonnetFile struct {
+	// Dir is the directory holding jsonnetfile.json, relative to the
+	// repository.
+	Dir     string            `yaml:"dir"`
+	Options DependencyOptions `yaml:",inline"`
+}
+
+// UnmarshalYAML unmarshals a JsonnetFile. The value can either be a string
+// or the JsonnetFile struct.
+func (j *JsonnetFile) UnmarshalYAML(f func(v interface{}) error) error {
+	var (
+		stringError error
+		objectError error
+	)
+
+	// Try as a raw string
+	var s string
+	stringError = f(&s)
+	if stringError == nil {
+		j.Dir = s
+		return nil
+	}
+
+	// Then a whole object
+	type jsonnetFile JsonnetFile
+	var v jsonnetFile
+	objectError = f(&v)
+	if objectError == nil {
+		*j = JsonnetFile(v)
+		return nil
+	}
+
+	return fmt.Errorf(
+		"could not parse jsonnet dependency as a string (%s) or an object (%s)",
+		stringError,
+		objectError,
+	)
+}
+
+// NewJsonnet creates a new Jsonnet tracker. Directories in check are
+// relative to repo.
+func NewJsonnet(repo string, check []JsonnetFile) *Jsonnet {
+	return &Jsonnet{repo: repo, check: check}
+}
+
+// CheckOutdated will return the list of jsonnet dependencies that can be updated.
+func (c *Jsonnet) CheckOutdated(ctx context.Context) ([]Dependency, error) {
+	var (
+		outdated []Dependency
+
+		// Many dependencies share the same remote (e.g., several mixins from
+		// one repository), so only list each remote once.
+		remotes = make(map[string]*gitRefs)
+	)
+
+	for _, jf := range c.check {
+		dir := filepath.Join(c.repo, jf.Dir)
+
+		deps, err := readJsonnetfile(filepath.Join(dir, "jsonnetfile.json"))
+		if err != nil {
+			return nil, err
+		}
+		locked, err := readJsonnetfile(filepath.Join(dir, "jsonnetfile.lock.json"))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		lockedVersions := make(map[string]string, len(locked))
+		for _, dep := range locked {
+			if dep.Source.Git != nil {
+				lockedVersions[dep.Source.Git.name()] = dep.Version
+			}
+		}
+
+		for _, dep := range deps {
+			// Local dependencies are part of the repository and can't be
+			// outdated.
+			if dep.Source.Git == nil {
+				continue
+			}
+			name := dep.Source.Git.name()
+
+			refs, ok := remotes[dep.Source.Git.Remote]
+			if !ok {
+				refs, err = lsRemote(ctx, dep.Source.Git.Remote)
+				if err != nil {
+					return nil, fmt.Errorf("couldn't list refs for %s: %w", name, err)
+				}
+				remotes[dep.Source.Git.Remote] = refs
+			}
+
+			update, ok, err := checkJsonnetDependency(name, dep.Version, lockedVersions[name], refs, jf.Options)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			} else if !ok {
+				continue
+			}
+			update.Name = name
+			outdated = append(outdated, update)
+		}
+	}
+
+	return outdated, nil
+}
+
+// checkJsonnetDependency determines whether the dependency name, which
+// requests version and is locked at the commit locked, is outdated. locked
+// may be empty if there is no lock file. Dependencies pinned to tags which
+// aren't valid versions are logged and skipped.
+func checkJsonnetDependency(name, version, locked string, refs *gitRefs, opts DependencyOptions) (Dependency, bool, error) {
+	// jsonnet-bundler tracks master when no version is specified.
+	if version == "" {
+		version = "master"
+	}
+
+	scheme, err := opts.scheme()
+	if err != nil {
+		return Dependency{}, false, err
+	}
+
+	// Dependency pinned to a tag: look for newer tags.
+	if _, ok := refs.Tags[version]; ok {
+		if _, ok := scheme.Parse(version); !ok {
+			log.Printf("Ignoring %s: tag %q is not a valid %s version", name, version, opts.schemeName())
+			return Dependency{}, false, nil
+		}
+
+		var available []Dependency
+		for _, tag := range refs.TagNames() {
+			available = append(available, Dependency{LatestVersion: tag})
+		}
+		available, err := opts.sortReleases(available)
+		if err != nil {
+			return Dependency{}, false, err
+		}
+
+		// git doesn't advertise when tags were created, so there is no
+		// publish time to check against.
+		latest, ok, err := opts.newestUpdate(version, available, nil)
+		if !ok || err != nil {
+			return Dependency{}, false, err
+		}
+		return Dependency{CurrentVersion: version, LatestVersion: latest.LatestVersion}, true, nil
+	}
+
+	// Dependency following a branch: the lock file determines which commit
+	// is in use.
+	if head, ok := refs.Branches[version]; ok {
+		if locked == "" || locked == head {
+			return Dependency{}, false, nil
+		}
+		return Dependency{Kind: UpdateKindCommits, CurrentVersion: shortHash(locked), LatestVersion: shortHash(head)}, true, nil
+	}
+
+	// Otherwise the dependency is pinned to a specific commit; compare it
+	// against the default branch.
+	if refs.Head == "" || strings.HasPrefix(refs.Head, version) {
+		return Dependency{}, false, nil
+	}
+	return Dependency{Kind: UpdateKindCommits, CurrentVersion: shortHash(version), LatestVersion: shortHash(refs.Head)}, true, nil
+}
+
+// jsonnetfile is the format of jsonnetfile.json and jsonnetfile.lock.json.
+type jsonnetfile struct {
+	Dependencies []jsonnetDependency `json:"dependencies"`
+}
+
+type jsonnetDependency struct {
+	Source struct {
+		Git   *jsonnetGitSource `json:"git"`
+		Local *struct {
+			Directory string `json:"directory"`
+		} `json:"local"`
+	} `json:"source"`
+	Version string `json:"version"`
+}
+
+type jsonnetGitSource struct {
+	Remote string `json:"remote"`
+	Subdir string `json:"subdir"`
+}
+
+// name returns the name of the dependency in the same format used by
+// jsonnet-bundler for its vendor directory, e.g.,
+// github.com/grafana/jsonnet-libs/grafana-builder.
+func (s *jsonnetGitSource) name() string {
+	name := remoteName(s.Remote)
+	if s.Subdir != "" {
+		name = path.Join(name, s.Subdir)
+	}
+	return name
+}
+
+func readJsonnetfile(path string) ([]jsonnetDependency, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var jf jsonnetfile
+	if err := json.Unmarshal(b, &jf); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return jf.Dependencies, nil
+}
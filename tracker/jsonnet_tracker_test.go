@@ -0,0 +1,126 @@
+package tracker
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+func TestParseJsonnetFile(t *testing.T) {
+	tt := []struct {
+		input  string
+		expect JsonnetFile
+	}{
+		{
+			input:  `"operations/mixin"`,
+			expect: JsonnetFile{Dir: "operations/mixin"},
+		},
+		{
+			input: `{
+				"dir": "operations/mixin",
+				"ignore_version_pattern": "foo",
+			}`,
+			expect: JsonnetFile{
+				Dir: "operations/mixin",
+				Options: DependencyOptions{
+					IgnoreVersionPattern: (*Regexp)(regexp.MustCompile("foo")),
+				},
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		var actual JsonnetFile
+		err := yaml.Unmarshal([]byte(tc.input), &actual)
+		require.NoError(t, err)
+		require.Equal(t, tc.expect, actual)
+	}
+}
+
+func TestJsonnetGitSourceName(t *testing.T) {
+	tt := []struct {
+		source jsonnetGitSource
+		expect string
+	}{
+		{
+			source: jsonnetGitSource{Remote: "https://github.com/grafana/jsonnet-libs.git", Subdir: "grafana-builder"},
+			expect: "github.com/grafana/jsonnet-libs/grafana-builder",
+		},
+		{
+			source: jsonnetGitSource{Remote: "https://github.com/prometheus/node_exporter.git", Subdir: "docs/node-mixin"},
+			expect: "github.com/prometheus/node_exporter/docs/node-mixin",
+		},
+		{
+			source: jsonnetGitSource{Remote: "git@github.com:grafana/jsonnet-libs.git"},
+			expect: "github.com/grafana/jsonnet-libs",
+		},
+	}
+
+	for _, tc := range tt {
+		require.Equal(t, tc.expect, tc.source.name())
+	}
+}
+
+func TestCheckJsonnetDependency(t *testing.T) {
+	refs := &gitRefs{
+		Head:     "cccccccccccccccccccccccccccccccccccccccc",
+		Branches: map[string]string{"master": "cccccccccccccccccccccccccccccccccccccccc"},
+		Tags: map[string]string{
+			"v0.1.0":      "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+			"v0.2.0":      "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb",
+			"nightly":     "cccccccccccccccccccccccccccccccccccccccc",
+			"release-1.2": "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+		},
+	}
+
+	tt := []struct {
+		name            string
+		version, locked string
+		expect          *Dependency
+	}{
+		{
+			name:    "outdated tag",
+			version: "v0.1.0",
+			expect:  &Dependency{CurrentVersion: "v0.1.0", LatestVersion: "v0.2.0"},
+		},
+		{
+			name:    "latest tag",
+			version: "v0.2.0",
+		},
+		{
+			name:    "unparsable tag",
+			version: "release-1.2",
+		},
+		{
+			name:    "outdated branch",
+			version: "master",
+			locked:  "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+			expect:  &Dependency{Kind: UpdateKindCommits, CurrentVersion: "aaaaaaa", LatestVersion: "ccccccc"},
+		},
+		{
+			name:    "default branch",
+			locked:  "cccccccccccccccccccccccccccccccccccccccc",
+			version: "",
+		},
+		{
+			name:    "outdated commit",
+			version: "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb",
+			expect:  &Dependency{Kind: UpdateKindCommits, CurrentVersion: "bbbbbbb", LatestVersion: "ccccccc"},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, ok, err := checkJsonnetDependency("github.com/grafana/jsonnet-libs", tc.version, tc.locked, refs, DependencyOptions{})
+			require.NoError(t, err)
+			if tc.expect == nil {
+				require.False(t, ok)
+				return
+			}
+			require.True(t, ok)
+			require.Equal(t, *tc.expect, actual)
+		})
+	}
+}
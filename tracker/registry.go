@@ -0,0 +1,327 @@
+package tracker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	// dockerHubRegistry is the host of the Docker Hub registry API, used for
+	// images without a registry such as grafana/grafana.
+	dockerHubRegistry = "registry-1.docker.io"
+
+	// registryPageSize is the number of tags requested per page from a
+	// registry.
+	registryPageSize = 1000
+	// registryMaxPages caps the number of pages of tags read for a single
+	// image.
+	registryMaxPages = 10
+)
+
+// imageRef is a reference to a container image repository, without a tag.
+type imageRef struct {
+	// Registry is the host of the registry, such as ghcr.io or
+	// localhost:5000.
+	Registry string
+	// Repository is the path of the repository within the registry, such as
+	// library/alpine.
+	Repository string
+}
+
+// parseImageRef parses an image name without a tag, such as alpine,
+// grafana/grafana, or ghcr.io/grafana/agent. Images without a registry are
+// on Docker Hub.
+func parseImageRef(image string) (imageRef, error) {
+	if image == "" || strings.ContainsAny(image, "@ ") {
+		return imageRef{}, fmt.Errorf("invalid image %q", image)
+	}
+
+	// The first component is a registry if it looks like a host, following
+	// the rules of the docker CLI.
+	first, rest, ok := strings.Cut(image, "/")
+	switch {
+	case ok && (first == "docker.io" || first == "index.docker.io"):
+		image = rest
+	case ok && (strings.ContainsAny(first, ".:") || first == "localhost"):
+		return imageRef{Registry: first, Repository: rest}, nil
+	}
+	if !strings.Contains(image, "/") {
+		image = "library/" + image
+	}
+	return imageRef{Registry: dockerHubRegistry, Repository: image}, nil
+}
+
+// splitImageTag splits an image reference such as grafana/grafana:10.2.0
+// into the image and its tag. The tag is empty if the reference has none.
+func splitImageTag(ref string) (image, tag string) {
+	i := strings.LastIndex(ref, ":")
+	if i < 0 || strings.Contains(ref[i:], "/") {
+		// The colon is part of the registry host, such as
+		// localhost:5000/app.
+		return ref, ""
+	}
+	return ref[:i], ref[i+1:]
+}
+
+// registryClient lists tags of container images using the Docker Registry
+// HTTP API V2, which is implemented by OCI distribution registries.
+type registryClient struct {
+	cli *http.Client
+
+	// auths maps registries to base64-encoded "username:password"
+	// credentials from the docker config file.
+	auths map[string]string
+}
+
+// newRegistryClient creates a registryClient using credentials from the
+// docker config file, at $DOCKER_CONFIG/config.json or
+// ~/.docker/config.json. Credential helpers aren't supported.
+func newRegistryClient() *registryClient {
+	c := &registryClient{cli: http.DefaultClient}
+
+	dir := os.Getenv("DOCKER_CONFIG")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return c
+		}
+		dir = filepath.Join(home, ".docker")
+	}
+	b, err := os.ReadFile(filepath.Join(dir, "config.json"))
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Printf("Ignoring docker config: %s", err)
+		}
+		return c
+	}
+
+	var cfg struct {
+		Auths map[string]struct {
+			Auth string `json:"auth"`
+		} `json:"auths"`
+	}
+	if err := json.Unmarshal(b, &cfg); err != nil {
+		log.Printf("Ignoring docker config: %s", err)
+		return c
+	}
+	c.auths = make(map[string]string, len(cfg.Auths))
+	for host, a := range cfg.Auths {
+		if a.Auth != "" {
+			c.auths[host] = a.Auth
+		}
+	}
+	return c
+}
+
+// credentials returns the base64-encoded credentials for a registry, or an
+// empty string if there are none.
+func (c *registryClient) credentials(registry string) string {
+	keys := []string{registry, "https://" + registry}
+	if registry == dockerHubRegistry {
+		keys = []string{"https://index.docker.io/v1/", "index.docker.io", "docker.io", registry}
+	}
+	for _, k := range keys {
+		if auth, ok := c.auths[k]; ok {
+			return auth
+		}
+	}
+	return ""
+}
+
+// Tags returns all tags of an image.
+func (c *registryClient) Tags(ctx context.Context, ref imageRef) ([]string, error) {
+	var (
+		tags []string
+
+		// authorization is the value of the Authorization header, set once
+		// the registry asks for authentication.
+		authorization string
+	)
+
+	endpoint := fmt.Sprintf("%s://%s/v2/%s/tags/list?n=%d", registryScheme(ref.Registry), ref.Registry, ref.Repository, registryPageSize)
+	for page := 0; page < registryMaxPages; page++ {
+		resp, err := c.get(ctx, endpoint, authorization)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode == http.StatusUnauthorized && authorization == "" {
+			challenge := resp.Header.Get("WWW-Authenticate")
+			resp.Body.Close()
+
+			authorization, err = c.authenticate(ctx, ref, challenge)
+			if err != nil {
+				return nil, fmt.Errorf("couldn't authenticate to %s: %w", ref.Registry, err)
+			}
+			resp, err = c.get(ctx, endpoint, authorization)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		var list struct {
+			Tags []string `json:"tags"`
+		}
+		err = decodeRegistryResponse(resp, &list)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, list.Tags...)
+
+		next := nextLink(resp.Header.Get("Link"))
+		if next == "" {
+			return tags, nil
+		}
+		u, err := resp.Request.URL.Parse(next)
+		if err != nil {
+			return nil, fmt.Errorf("invalid next page %q: %w", next, err)
+		}
+		endpoint = u.String()
+	}
+
+	log.Printf("Only checking the first %d tags of %s/%s", registryMaxPages*registryPageSize, ref.Registry, ref.Repository)
+	return tags, nil
+}
+
+func (c *registryClient) get(ctx context.Context, endpoint, authorization string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	return c.cli.Do(req)
+}
+
+// authenticate answers an authentication challenge from a registry,
+// returning the value of the Authorization header to retry with. Bearer
+// challenges request a token from the realm of the challenge, which is done
+// anonymously if there are no credentials for the registry.
+func (c *registryClient) authenticate(ctx context.Context, ref imageRef, challenge string) (string, error) {
+	scheme, params := parseChallenge(challenge)
+	creds := c.credentials(ref.Registry)
+
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if creds == "" {
+			return "", fmt.Errorf("no credentials found")
+		}
+		return "Basic " + creds, nil
+
+	case "bearer":
+		realm, err := url.Parse(params["realm"])
+		if err != nil || params["realm"] == "" {
+			return "", fmt.Errorf("invalid token realm %q", params["realm"])
+		}
+		q := realm.Query()
+		if service := params["service"]; service != "" {
+			q.Set("service", service)
+		}
+		scope := params["scope"]
+		if scope == "" {
+			scope = "repository:" + ref.Repository + ":pull"
+		}
+		q.Set("scope", scope)
+		realm.RawQuery = q.Encode()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+		if err != nil {
+			return "", err
+		}
+		if creds != "" {
+			req.Header.Set("Authorization", "Basic "+creds)
+		}
+		resp, err := c.cli.Do(req)
+		if err != nil {
+			return "", err
+		}
+
+		var token struct {
+			Token       string `json:"token"`
+			AccessToken string `json:"access_token"`
+		}
+		if err := decodeRegistryResponse(resp, &token); err != nil {
+			return "", err
+		}
+		if token.Token == "" {
+			token.Token = token.AccessToken
+		}
+		if token.Token == "" {
+			return "", fmt.Errorf("no token returned by %s", realm.Host)
+		}
+		return "Bearer " + token.Token, nil
+
+	default:
+		return "", fmt.Errorf("unsupported authentication challenge %q", challenge)
+	}
+}
+
+// decodeRegistryResponse decodes a JSON response into v and closes its body.
+func decodeRegistryResponse(resp *http.Response, v interface{}) error {
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s: unexpected status %s: %s", resp.Request.URL.Redacted(), resp.Status, strings.TrimSpace(string(b)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response from %s: %w", resp.Request.URL.Redacted(), err)
+	}
+	return nil
+}
+
+// challengeParamRegexp matches a parameter of a WWW-Authenticate header.
+var challengeParamRegexp = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// parseChallenge parses a WWW-Authenticate header such as
+// `Bearer realm="https://auth.docker.io/token",service="registry.docker.io"`.
+func parseChallenge(challenge string) (scheme string, params map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(challenge), " ")
+	params = make(map[string]string)
+	for _, m := range challengeParamRegexp.FindAllStringSubmatch(rest, -1) {
+		params[strings.ToLower(m[1])] = m[2]
+	}
+	return scheme, params
+}
+
+// nextLinkRegexp matches the next page of a Link header, such as
+// `</v2/library/alpine/tags/list?last=3.19&n=1000>; rel="next"`.
+var nextLinkRegexp = regexp.MustCompile(`<([^>]+)>\s*;\s*rel="?next"?`)
+
+// nextLink returns the URL of the next page from a Link header, or an empty
+// string if there is no next page.
+func nextLink(header string) string {
+	m := nextLinkRegexp.FindStringSubmatch(header)
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
+
+// registryScheme returns the scheme used to connect to a registry. Like the
+// docker daemon, plain http is only used for registries on the loopback
+// interface.
+func registryScheme(registry string) string {
+	host := registry
+	if h, _, err := net.SplitHostPort(registry); err == nil {
+		host = h
+	}
+	if host == "localhost" {
+		return "http"
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return "http"
+	}
+	return "https"
+}
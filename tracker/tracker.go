@@ -3,6 +3,8 @@ package tracker
 import (
 	"context"
 	"regexp"
+	"strings"
+	"time"
 
 	"github.com/google/go-github/v48/github"
 )
@@ -19,16 +21,125 @@ type Dependency struct {
 	Name           string
 	CurrentVersion string
 	LatestVersion  string
+
+	// Kind is the kind of update. Defaults to UpdateKindVersion.
+	Kind UpdateKind
+
+	// Reason is the upstream explanation for a retracted or deprecated
+	// dependency, if any.
+	Reason string
+
+	// NewName is the name of the dependency at LatestVersion when it differs
+	// from Name, such as the module path of a new major version of a Go
+	// module.
+	NewName string
+
+	// UsedBy lists where the dependency is used within the repository, such
+	// as the module "./operator" or the annotated line "Makefile:12". Only
+	// set by trackers which check multiple modules or files.
+	UsedBy []string
+
+	// Replacement is set when the dependency is replaced by another module
+	// or a local directory, such as "github.com/fork/project v1.2.3" or
+	// "../project". Go modules tracking their replacement are named
+	// "original => replacement" and report versions of the replacement.
+	Replacement string
+
+	// CommitsAhead and CompareURL describe the commits between
+	// CurrentVersion and LatestVersion. They are only set by trackers which
+	// can compare commits, such as for Go modules pinned to pseudo-versions.
+	CommitsAhead int
+	CompareURL   string
+
+	// Commits lists the commits between CurrentVersion and LatestVersion,
+	// oldest first. It is only set for forks, and may be truncated to fewer
+	// than CommitsAhead commits.
+	Commits []Commit
+
+	// URL, PublishedAt, and ReleaseNotes describe the release of
+	// LatestVersion. They are only set by trackers which have access to
+	// release information.
+	URL          string
+	PublishedAt  time.Time
+	ReleaseNotes string
+}
+
+// Commit is a commit which is part of an update.
+type Commit struct {
+	SHA string
+	// Summary is the first line of the commit message.
+	Summary string
+	Author  string
+	URL     string
+}
+
+// UpdateKind is a kind of update to a dependency.
+type UpdateKind string
+
+// Supported UpdateKind values.
+const (
+	// UpdateKindVersion is an update to a newer version of the same
+	// dependency.
+	UpdateKindVersion UpdateKind = ""
+	// UpdateKindMajorVersion is an update to a new major version of a Go
+	// module, which is published under a different module path. NewName is
+	// set to the new module path.
+	UpdateKindMajorVersion UpdateKind = "major_version"
+	// UpdateKindCommits is an update to the newest commit of a branch, such
+	// as the default branch of a dependency pinned to a commit which isn't
+	// part of a tagged release, or the upstream branch of a fork.
+	// CommitsAhead is set to the number of new commits.
+	UpdateKindCommits UpdateKind = "commits"
+	// UpdateKindRetracted reports that CurrentVersion has been retracted by
+	// its authors. LatestVersion is set if there is a version to update to.
+	UpdateKindRetracted UpdateKind = "retracted"
+	// UpdateKindDeprecated reports that the dependency has been deprecated
+	// by its authors. LatestVersion is not set.
+	UpdateKindDeprecated UpdateKind = "deprecated"
+	// UpdateKindUnsupported reports that CurrentVersion is no longer
+	// supported upstream. LatestVersion is set to the newest version.
+	UpdateKindUnsupported UpdateKind = "unsupported"
+)
+
+// IsDeprecation returns true if the dependency is reported because it is
+// retracted, deprecated, or unsupported rather than because an update is
+// available.
+func (k UpdateKind) IsDeprecation() bool {
+	return k == UpdateKindRetracted || k == UpdateKindDeprecated || k == UpdateKindUnsupported
 }
 
 // New creates a new Tracker that can return outdated dependencies.
 func New(c *Config, repo string, cli *github.Client) Tracker {
 	var trackers []Tracker
-	if len(c.GoModules) > 0 {
-		trackers = append(trackers, NewGoModules(repo, c.GoModules))
+	if c.GoModules.Enabled() {
+		trackers = append(trackers, NewGoModules(repo, c.GoModules, cli))
+	}
+	if c.GoToolchain.Enabled() {
+		trackers = append(trackers, NewGoToolchain(repo, c.GoToolchain))
 	}
 	if len(c.GithubDeps) > 0 {
-		trackers = append(trackers, NewGithub(c.GithubDeps, cli))
+		trackers = append(trackers, NewGithub(repo, c.GithubDeps, cli))
+	}
+	if len(c.Forks) > 0 {
+		trackers = append(trackers, NewForks(c.Forks, cli))
+	}
+	if len(c.GitlabDeps) > 0 {
+		trackers = append(trackers, NewGitlab(repo, c.GitlabDeps, c.GitlabURL, c.GitlabToken))
+	}
+	if len(c.GitDeps) > 0 {
+		trackers = append(trackers, NewGit(repo, c.GitDeps))
+	}
+	if len(c.ContainerImages) > 0 {
+		trackers = append(trackers, NewContainerImages(c.ContainerImages))
+	}
+	if c.ImageDiscovery.Enabled {
+		trackers = append(trackers, NewImageDiscovery(repo, c.ImageDiscovery))
+	}
+	if c.Annotations.Enabled {
+		trackers = append(trackers, NewAnnotations(repo, c.Annotations, cli, c.GitlabURL, c.GitlabToken))
+	}
+	if len(c.JsonnetDeps) > 0 {
+		trackers = append(trackers, NewJsonnet(repo, c.JsonnetDeps))
 	}
 	return &Multi{trackers: trackers}
 }
@@ -53,3 +164,46 @@ func (m *Multi) CheckOutdated(ctx context.Context) ([]Dependency, error) {
 
 	return deps, nil
 }
+
+// UsedByText returns UsedBy as an English list, such as
+// "./operator and ./tools".
+func (d Dependency) UsedByText() string {
+	switch len(d.UsedBy) {
+	case 0:
+		return ""
+	case 1:
+		return d.UsedBy[0]
+	case 2:
+		return d.UsedBy[0] + " and " + d.UsedBy[1]
+	default:
+		return strings.Join(d.UsedBy[:len(d.UsedBy)-1], ", ") + ", and " + d.UsedBy[len(d.UsedBy)-1]
+	}
+}
+
+// usage is where a version of a dependency is used within the repository.
+type usage struct {
+	// Source describes where the version is declared, such as
+	// "Dockerfile:3" or "go.mod (toolchain)".
+	Source  string
+	Version string
+}
+
+// setUsedBy sets UsedBy to the sources of used, for a dependency reported
+// once for several usages which share an update. If the usages don't all use
+// CurrentVersion, each source is followed by its version, such as
+// "Dockerfile:3 at 3.18", since CurrentVersion is only one of them.
+func (d *Dependency) setUsedBy(used []usage) {
+	same := true
+	for _, u := range used {
+		same = same && u.Version == d.CurrentVersion
+	}
+
+	d.UsedBy = make([]string, 0, len(used))
+	for _, u := range used {
+		if same {
+			d.UsedBy = append(d.UsedBy, u.Source)
+		} else {
+			d.UsedBy = append(d.UsedBy, u.Source+" at "+u.Version)
+		}
+	}
+}
@@ -0,0 +1,334 @@
+package tracker
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/mod/semver"
+)
+
+// Version is a parsed version, independent of the scheme it was parsed
+// from.
+type Version struct {
+	// Segments are the numeric components of the version, most significant
+	// first.
+	Segments []int
+	// Prerelease is the prerelease identifier of the version without the
+	// leading "-". Empty for releases.
+	Prerelease string
+}
+
+// Segment returns the ith segment of v, or 0 if v has fewer segments.
+func (v Version) Segment(i int) int {
+	if i < len(v.Segments) {
+		return v.Segments[i]
+	}
+	return 0
+}
+
+// Compare returns -1, 0, or 1 if v is lower than, equal to, or higher than
+// o. Missing segments are treated as 0, so 1.2 is equal to 1.2.0. Releases
+// are higher than prereleases with the same segments, and prereleases are
+// ordered using semantic versioning rules.
+func (v Version) Compare(o Version) int {
+	n := len(v.Segments)
+	if len(o.Segments) > n {
+		n = len(o.Segments)
+	}
+	for i := 0; i < n; i++ {
+		switch a, b := v.Segment(i), o.Segment(i); {
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		}
+	}
+
+	switch {
+	case v.Prerelease == o.Prerelease:
+		return 0
+	case v.Prerelease == "":
+		return 1
+	case o.Prerelease == "":
+		return -1
+	}
+
+	a, b := "v0.0.0-"+v.Prerelease, "v0.0.0-"+o.Prerelease
+	if semver.IsValid(a) && semver.IsValid(b) {
+		return semver.Compare(a, b)
+	}
+	return strings.Compare(v.Prerelease, o.Prerelease)
+}
+
+// String returns the normalized form of v, such as 1.2.0-rc.1.
+func (v Version) String() string {
+	segments := make([]string, len(v.Segments))
+	for i, s := range v.Segments {
+		segments[i] = strconv.Itoa(s)
+	}
+	s := strings.Join(segments, ".")
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+	return s
+}
+
+// VersionScheme parses versions.
+type VersionScheme interface {
+	// Parse parses s as a version. ok is false if s isn't a valid version
+	// in the scheme.
+	Parse(s string) (v Version, ok bool)
+}
+
+// VersionSchemeName is the name of a VersionScheme in the config.
+type VersionSchemeName string
+
+// Supported VersionSchemeName values.
+const (
+	// VersionSchemeSemver parses semantic versions such as v1.2.3. The "v"
+	// prefix is optional. This is the default.
+	VersionSchemeSemver VersionSchemeName = "semver"
+	// VersionSchemeCalver parses calendar versions such as 2024.05.1.
+	VersionSchemeCalver VersionSchemeName = "calver"
+	// VersionSchemeNumeric parses any dot-separated list of numbers after a
+	// non-numeric prefix, such as 1.2 or release-42.
+	VersionSchemeNumeric VersionSchemeName = "numeric"
+	// VersionSchemeRegex extracts versions with the version_pattern regex
+	// and parses them like VersionSchemeNumeric.
+	VersionSchemeRegex VersionSchemeName = "regex"
+)
+
+// UnmarshalYAML unmarshals a VersionSchemeName, ensuring that it is valid.
+func (n *VersionSchemeName) UnmarshalYAML(f func(v interface{}) error) error {
+	var s string
+	if err := f(&s); err != nil {
+		return err
+	}
+	switch v := VersionSchemeName(s); v {
+	case VersionSchemeSemver, VersionSchemeCalver, VersionSchemeNumeric, VersionSchemeRegex:
+		*n = v
+		return nil
+	default:
+		return fmt.Errorf(
+			"invalid version scheme %q: expected %q, %q, %q, or %q",
+			s, VersionSchemeSemver, VersionSchemeCalver, VersionSchemeNumeric, VersionSchemeRegex,
+		)
+	}
+}
+
+// scheme returns the VersionScheme to use for versions of a dependency.
+func (o *DependencyOptions) scheme() (VersionScheme, error) {
+	switch o.VersionScheme {
+	case "", VersionSchemeSemver:
+		return semverScheme{}, nil
+	case VersionSchemeCalver:
+		return calverScheme{}, nil
+	case VersionSchemeNumeric:
+		return numericScheme{}, nil
+	case VersionSchemeRegex:
+		if o.VersionPattern == nil {
+			return nil, fmt.Errorf("version_scheme %q requires version_pattern to be set", o.VersionScheme)
+		}
+		return regexScheme{pattern: o.VersionPattern}, nil
+	default:
+		return nil, fmt.Errorf("unknown version scheme %q", o.VersionScheme)
+	}
+}
+
+type semverScheme struct{}
+
+func (semverScheme) Parse(s string) (Version, bool) {
+	if !strings.HasPrefix(s, "v") {
+		s = "v" + s
+	}
+	if !semver.IsValid(s) {
+		return Version{}, false
+	}
+	// Canonical fills in missing segments and drops build metadata.
+	return parseDotted(semver.Canonical(s))
+}
+
+var calverRegexp = regexp.MustCompile(`^v?(\d{4}|\d{2})\.\d{1,2}(\.\d+){0,2}(-[0-9A-Za-z.-]+)?$`)
+
+type calverScheme struct{}
+
+func (calverScheme) Parse(s string) (Version, bool) {
+	if !calverRegexp.MatchString(s) {
+		return Version{}, false
+	}
+	return parseDotted(s)
+}
+
+var numericRegexp = regexp.MustCompile(`^[^0-9]*(\d+(\.\d+)*(-[0-9A-Za-z.-]+)?)$`)
+
+type numericScheme struct{}
+
+func (numericScheme) Parse(s string) (Version, bool) {
+	m := numericRegexp.FindStringSubmatch(s)
+	if m == nil {
+		return Version{}, false
+	}
+	return parseDotted(m[1])
+}
+
+// regexScheme extracts a version with a regex. See Regexp.Extract for which
+// portion of the match is used.
+type regexScheme struct {
+	pattern *Regexp
+}
+
+func (r regexScheme) Parse(s string) (Version, bool) {
+	v, ok := r.pattern.Extract(s)
+	if !ok {
+		return Version{}, false
+	}
+	return parseDotted(v)
+}
+
+// parseDotted parses a version such as v1.2.3-rc.1 made of dot-separated
+// numbers with an optional "v" prefix and prerelease.
+func parseDotted(s string) (Version, bool) {
+	s = strings.TrimPrefix(s, "v")
+	s, prerelease, _ := strings.Cut(s, "-")
+
+	var v Version
+	for _, seg := range strings.Split(s, ".") {
+		n, err := strconv.Atoi(seg)
+		if err != nil || n < 0 {
+			return Version{}, false
+		}
+		v.Segments = append(v.Segments, n)
+	}
+	v.Prerelease = prerelease
+	return v, true
+}
+
+// sortReleases sorts available releases by LatestVersion from newest to
+// oldest. Releases whose LatestVersion is not a valid version in the version
+// scheme of o are discarded.
+func (o *DependencyOptions) sortReleases(available []Dependency) ([]Dependency, error) {
+	scheme, err := o.scheme()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		valid    = make([]Dependency, 0, len(available))
+		versions = make(map[string]Version, len(available))
+	)
+	for _, r := range available {
+		if v, ok := scheme.Parse(r.LatestVersion); ok {
+			valid = append(valid, r)
+			versions[r.LatestVersion] = v
+		}
+	}
+	sort.SliceStable(valid, func(i, j int) bool {
+		return versions[valid[i].LatestVersion].Compare(versions[valid[j].LatestVersion]) > 0
+	})
+	return valid, nil
+}
+
+// publishedFunc looks up when the version of an available release was
+// published.
+type publishedFunc func(release Dependency) (time.Time, error)
+
+// newestUpdate returns the newest release from available which is newer than
+// current and permitted by o. available must be sorted from newest to
+// oldest, as returned by sortReleases.
+//
+// If o has a minimum age, releases without a PublishedAt time are looked up
+// with published. published may be nil if publish times can't be determined.
+func (o *DependencyOptions) newestUpdate(current string, available []Dependency, published publishedFunc) (Dependency, bool, error) {
+	scheme, err := o.scheme()
+	if err != nil {
+		return Dependency{}, false, err
+	}
+	currentVersion, ok := scheme.Parse(current)
+	if !ok {
+		return Dependency{}, false, fmt.Errorf("current version %q is not a valid %s version", current, o.schemeName())
+	}
+
+	now := time.Now()
+
+	for _, r := range available {
+		v, ok := scheme.Parse(r.LatestVersion)
+		if !ok {
+			continue
+		}
+		if currentVersion.Compare(v) != -1 {
+			break
+		}
+		if !o.allows(currentVersion, r.LatestVersion, v) {
+			continue
+		}
+
+		if old, err := o.oldEnough(&r, published, now); err != nil {
+			return Dependency{}, false, err
+		} else if !old {
+			continue
+		}
+		return r, true, nil
+	}
+	return Dependency{}, false, nil
+}
+
+// oldEnough returns true if r was published at least o.MinAge before now.
+// If r doesn't have a PublishedAt time, it is looked up with published and
+// set on r. published may be nil if publish times can't be determined.
+func (o *DependencyOptions) oldEnough(r *Dependency, published publishedFunc, now time.Time) (bool, error) {
+	if o.MinAge <= 0 {
+		return true, nil
+	}
+	if r.PublishedAt.IsZero() {
+		if published == nil {
+			return false, fmt.Errorf("min_age can't be checked: publish time of %s is unknown", r.LatestVersion)
+		}
+		t, err := published(*r)
+		if err != nil {
+			return false, fmt.Errorf("couldn't get publish time of %s: %w", r.LatestVersion, err)
+		}
+		r.PublishedAt = t
+	}
+	return now.Sub(r.PublishedAt) >= o.MinAge, nil
+}
+
+// schemeName returns the name of the version scheme of o.
+func (o *DependencyOptions) schemeName() VersionSchemeName {
+	if o.VersionScheme == "" {
+		return VersionSchemeSemver
+	}
+	return o.VersionScheme
+}
+
+// allows returns true if o permits updating from current to update. raw is
+// the unparsed form of update.
+func (o *DependencyOptions) allows(current Version, raw string, update Version) bool {
+	switch {
+	case o.IgnoreVersionPattern.Matches(raw):
+		return false
+	case !o.UpdatePolicy.allows(current, update):
+		return false
+	case o.Ignore.Matches(update):
+		return false
+	case o.Allowed != nil && !o.Allowed.Matches(update):
+		return false
+	default:
+		return true
+	}
+}
+
+// allows returns true if p permits updating from current to update.
+func (p UpdatePolicy) allows(current, update Version) bool {
+	switch p {
+	case UpdatePolicyMinor:
+		return current.Segment(0) == update.Segment(0)
+	case UpdatePolicyPatch:
+		return current.Segment(0) == update.Segment(0) && current.Segment(1) == update.Segment(1)
+	default:
+		return true
+	}
+}
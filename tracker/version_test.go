@@ -0,0 +1,199 @@
+package tracker
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSortReleases(t *testing.T) {
+	input := []Dependency{
+		{LatestVersion: "v1.2.0"},
+		{LatestVersion: "nightly"},
+		{LatestVersion: "v1.10.0"},
+		{LatestVersion: "v1.10.0-rc.1"},
+		{LatestVersion: "1.11.0"},
+		{LatestVersion: "v1.9.3"},
+	}
+	expect := []Dependency{
+		{LatestVersion: "1.11.0"},
+		{LatestVersion: "v1.10.0"},
+		{LatestVersion: "v1.10.0-rc.1"},
+		{LatestVersion: "v1.9.3"},
+		{LatestVersion: "v1.2.0"},
+	}
+	var opts DependencyOptions
+	actual, err := opts.sortReleases(input)
+	require.NoError(t, err)
+	require.Equal(t, expect, actual)
+}
+
+func TestVersionSchemes(t *testing.T) {
+	tt := []struct {
+		opts    DependencyOptions
+		input   string
+		expect  string
+		invalid bool
+	}{
+		{input: "v1.2.3", expect: "1.2.3"},
+		{input: "1.2", expect: "1.2.0"},
+		{input: "v1.2.3-rc.1+build", expect: "1.2.3-rc.1"},
+		{input: "2024.05.1", invalid: true},
+		{input: "nightly", invalid: true},
+
+		{opts: DependencyOptions{VersionScheme: VersionSchemeCalver}, input: "2024.05.1", expect: "2024.5.1"},
+		{opts: DependencyOptions{VersionScheme: VersionSchemeCalver}, input: "24.04", expect: "24.4"},
+		{opts: DependencyOptions{VersionScheme: VersionSchemeCalver}, input: "1.2.3", invalid: true},
+
+		{opts: DependencyOptions{VersionScheme: VersionSchemeNumeric}, input: "release-42", expect: "42"},
+		{opts: DependencyOptions{VersionScheme: VersionSchemeNumeric}, input: "1.2", expect: "1.2"},
+		{opts: DependencyOptions{VersionScheme: VersionSchemeNumeric}, input: "latest", invalid: true},
+
+		{
+			opts: DependencyOptions{
+				VersionScheme:  VersionSchemeRegex,
+				VersionPattern: (*Regexp)(regexp.MustCompile(`^build-(?P<version>\d+)-stable$`)),
+			},
+			input:  "build-17-stable",
+			expect: "17",
+		},
+		{
+			opts: DependencyOptions{
+				VersionScheme:  VersionSchemeRegex,
+				VersionPattern: (*Regexp)(regexp.MustCompile(`^build-(?P<version>\d+)-stable$`)),
+			},
+			input:   "build-17-unstable",
+			invalid: true,
+		},
+	}
+
+	for _, tc := range tt {
+		scheme, err := tc.opts.scheme()
+		require.NoError(t, err)
+
+		v, ok := scheme.Parse(tc.input)
+		require.Equal(t, !tc.invalid, ok, "unexpected result parsing %q", tc.input)
+		if ok {
+			require.Equal(t, tc.expect, v.String())
+		}
+	}
+}
+
+func TestVersion_Compare(t *testing.T) {
+	tt := []struct {
+		a, b   string
+		expect int
+	}{
+		{a: "1.2", b: "1.2.0", expect: 0},
+		{a: "1.10", b: "1.9", expect: 1},
+		{a: "1.0.0-rc.1", b: "1.0.0", expect: -1},
+		{a: "1.0.0-rc.2", b: "1.0.0-rc.10", expect: -1},
+		{a: "2024.5.1", b: "2024.12", expect: -1},
+	}
+
+	for _, tc := range tt {
+		a, ok := parseDotted(tc.a)
+		require.True(t, ok)
+		b, ok := parseDotted(tc.b)
+		require.True(t, ok)
+		require.Equal(t, tc.expect, a.Compare(b), "comparing %s and %s", tc.a, tc.b)
+	}
+}
+
+func TestNewestUpdate(t *testing.T) {
+	available := []Dependency{
+		{LatestVersion: "v2.0.0-rc.1"},
+		{LatestVersion: "v1.9.3"},
+		{LatestVersion: "v1.9.2"},
+	}
+
+	tt := []struct {
+		name    string
+		current string
+		opts    DependencyOptions
+		expect  string
+	}{
+		{
+			name:    "latest",
+			current: "v1.9.2",
+			expect:  "v2.0.0-rc.1",
+		},
+		{
+			name:    "latest ignored",
+			current: "v1.9.2",
+			opts:    DependencyOptions{IgnoreVersionPattern: (*Regexp)(regexp.MustCompile(`-rc\.\d+$`))},
+			expect:  "v1.9.3",
+		},
+		{
+			name:    "all newer versions ignored",
+			current: "v1.9.3",
+			opts:    DependencyOptions{IgnoreVersionPattern: (*Regexp)(regexp.MustCompile(`-rc\.\d+$`))},
+		},
+		{
+			name:    "minor policy",
+			current: "v1.9.2",
+			opts:    DependencyOptions{UpdatePolicy: UpdatePolicyMinor},
+			expect:  "v1.9.3",
+		},
+		{
+			name:    "patch policy",
+			current: "v1.8.0",
+			opts:    DependencyOptions{UpdatePolicy: UpdatePolicyPatch},
+		},
+		{
+			name:    "allowed constraint",
+			current: "v1.9.2",
+			opts:    DependencyOptions{Allowed: mustParseConstraint(t, "1.x")},
+			expect:  "v1.9.3",
+		},
+		{
+			name:    "ignore constraint",
+			current: "v1.9.2",
+			opts:    DependencyOptions{Ignore: mustParseConstraint(t, ">= 1.9.3")},
+		},
+		{
+			name:    "up to date",
+			current: "v2.0.0-rc.1",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, ok, err := tc.opts.newestUpdate(tc.current, available, nil)
+			require.NoError(t, err)
+			require.Equal(t, tc.expect != "", ok)
+			require.Equal(t, tc.expect, actual.LatestVersion)
+		})
+	}
+}
+
+func mustParseConstraint(t *testing.T, s string) *Constraint {
+	t.Helper()
+	c, err := ParseConstraint(s)
+	require.NoError(t, err)
+	return c
+}
+
+func TestNewestUpdate_MinAge(t *testing.T) {
+	now := time.Now()
+	available := []Dependency{
+		{LatestVersion: "v1.9.4"},
+		{LatestVersion: "v1.9.3", PublishedAt: now.Add(-time.Hour)},
+		{LatestVersion: "v1.9.2", PublishedAt: now.Add(-72 * time.Hour)},
+	}
+	opts := DependencyOptions{MinAge: 48 * time.Hour}
+
+	published := func(r Dependency) (time.Time, error) {
+		require.Equal(t, "v1.9.4", r.LatestVersion)
+		return now.Add(-time.Minute), nil
+	}
+	actual, ok, err := opts.newestUpdate("v1.9.0", available, published)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "v1.9.2", actual.LatestVersion)
+
+	_, _, err = opts.newestUpdate("v1.9.0", available, nil)
+	require.EqualError(t, err, "min_age can't be checked: publish time of v1.9.4 is unknown")
+}
@@ -0,0 +1,75 @@
+package tracker
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// maxScannedFileSize is the size of the largest file read when scanning the
+// repository. Larger files are unlikely to be hand-written.
+const maxScannedFileSize = 1 << 20
+
+// walkRepo calls fn with the path, relative to repo, and contents of each
+// regular file in repo. Directories named .git, files larger than
+// maxScannedFileSize, and paths matching any of the exclude globs are
+// skipped.
+func walkRepo(repo string, exclude []string, fn func(rel string, b []byte) error) error {
+	return filepath.WalkDir(repo, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(repo, file)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if d.IsDir() && d.Name() == ".git" || excluded(exclude, rel) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		if info, err := d.Info(); err != nil {
+			return err
+		} else if info.Size() > maxScannedFileSize {
+			return nil
+		}
+
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		return fn(rel, b)
+	})
+}
+
+// excluded returns true if rel matches any of the exclude globs. Like
+// .gitignore, globs without a "/" match any file or directory with a matching
+// name, such as node_modules or *_test.go, while other globs are matched
+// against the whole path relative to the repository, such as deploy/dev or
+// /build.
+func excluded(exclude []string, rel string) bool {
+	for _, pattern := range exclude {
+		anchored := strings.HasPrefix(pattern, "/")
+		pattern = strings.Trim(pattern, "/")
+		if anchored || strings.Contains(pattern, "/") {
+			if ok, _ := path.Match(pattern, rel); ok {
+				return true
+			}
+			continue
+		}
+		for _, elem := range strings.Split(rel, "/") {
+			if ok, _ := path.Match(pattern, elem); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
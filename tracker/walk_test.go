@@ -0,0 +1,31 @@
+package tracker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExcluded(t *testing.T) {
+	exclude := []string{"node_modules", "*_test.go", "deploy/dev", "/build/"}
+
+	tt := []struct {
+		rel    string
+		expect bool
+	}{
+		{rel: "node_modules", expect: true},
+		{rel: "web/node_modules", expect: true},
+		{rel: "web/node_modules/pkg/Makefile", expect: true},
+		{rel: "tracker/tracker_test.go", expect: true},
+		{rel: "tracker/tracker.go", expect: false},
+		{rel: "deploy/dev", expect: true},
+		{rel: "tools/deploy/dev", expect: false},
+		{rel: "build", expect: true},
+		{rel: "tools/build", expect: false},
+		{rel: "Makefile", expect: false},
+	}
+
+	for _, tc := range tt {
+		require.Equal(t, tc.expect, excluded(exclude, tc.rel), tc.rel)
+	}
+}